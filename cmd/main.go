@@ -2,32 +2,16 @@ package main
 
 import (
 	"context"
-	"log"
-	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 
-	"github.com/gamma-omg/trading-bot/internal/agent"
-	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/gamma-omg/trading-bot/internal/cli"
 )
 
 func main() {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	cfg, err := config.ReadFromFile(os.Getenv("CONFIG"))
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	logger := slog.Default()
-
-	r := agent.NewJsonReportBuilder(logger)
-	a, err := agent.NewTradingAgent(logger, *cfg, r)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	if err := a.Run(ctx); err != nil {
-		log.Fatal(err)
-	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := cli.Main(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	cancel()
+	os.Exit(code)
 }
@@ -4,6 +4,7 @@ go 1.24.0
 
 require (
 	github.com/alpacahq/alpaca-trade-api-go/v3 v3.9.0
+	github.com/klauspost/compress v1.18.0
 	github.com/pplcc/plotext v0.0.0-20180221170324-68ab3c6e05c3
 	github.com/shopspring/decimal v1.4.0
 	github.com/stretchr/testify v1.10.0
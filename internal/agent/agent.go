@@ -6,12 +6,15 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/gamma-omg/trading-bot/internal/config"
 	"github.com/gamma-omg/trading-bot/internal/market"
+	"github.com/shopspring/decimal"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -26,17 +29,25 @@ type tradingPlatform interface {
 	account
 }
 
+type barsClock interface {
+	Wait(ctx context.Context, symbol string, t time.Time) error
+	Done(symbol string)
+	Leave(symbol string)
+}
+
 type tradingStrategy interface {
 	Init() error
 	Run(ctx context.Context) error
+	Finish(ctx context.Context, closePosition bool) error
 }
 
-type tradingStrategyFactory func(cfg config.Strategy, asset *market.Asset) (tradingStrategy, error)
+type tradingStrategyFactory func(cfg config.Strategy, asset *market.Asset, journal tradingJournal) (tradingStrategy, error)
 
 type TradingAgent struct {
 	log             *slog.Logger
 	cfg             config.Config
 	bars            barsSource
+	clock           barsClock
 	strategyFactory tradingStrategyFactory
 	report          reportBuilder
 }
@@ -51,9 +62,10 @@ func NewTradingAgent(log *slog.Logger, cfg config.Config, report reportBuilder)
 		log:    log,
 		cfg:    cfg,
 		bars:   platform,
+		clock:  createClock(cfg),
 		report: report,
-		strategyFactory: func(cfg config.Strategy, asset *market.Asset) (tradingStrategy, error) {
-			ind, err := createIndicator(cfg.IndRef, asset)
+		strategyFactory: func(cfg config.Strategy, asset *market.Asset, journal tradingJournal) (tradingStrategy, error) {
+			ind, err := createIndicator(cfg.IndRef, asset, cfg.BarDuration())
 			if err != nil {
 				return nil, fmt.Errorf("failed to create trading strategy for symbol %s: %w", asset.Symbol, err)
 			}
@@ -62,22 +74,36 @@ func NewTradingAgent(log *slog.Logger, cfg config.Config, report reportBuilder)
 				takeProfit: cfg.TakeProfit,
 				stopLoss:   cfg.StopLoss,
 			}
-			return newTradingStrategy(asset, cfg, ind, validator, platform, platform, report, log), nil
+			return newTradingStrategy(asset, cfg, ind, validator, platform, platform, report, journal, log), nil
 		},
 	}
 	return a, nil
 }
 
-func (a *TradingAgent) Run(ctx context.Context) error {
+func (a *TradingAgent) Run(ctx context.Context) (err error) {
 	a.log.Info("starting agent")
 
+	journal, journalCloser, err := createJournal(a.cfg.Journal)
+	if err != nil {
+		return fmt.Errorf("failed to create trading journal: %w", err)
+	}
+	if journalCloser != nil {
+		defer func() {
+			if cerr := journalCloser.Close(); cerr != nil {
+				err = errors.Join(err, fmt.Errorf("failed to close trading journal: %w", cerr))
+			}
+		}()
+	}
+
 	grp, ctx := errgroup.WithContext(ctx)
 	for symbol, cfg := range a.cfg.Strategies {
 		symbol, cfg := symbol, cfg
 
 		grp.Go(func() (err error) {
+			defer a.clock.Leave(symbol)
+
 			asset := market.NewAsset(symbol, cfg.MarketBuffer)
-			s, err := a.strategyFactory(cfg, asset)
+			s, err := a.strategyFactory(cfg, asset, journal)
 			if err != nil {
 				return fmt.Errorf("failed to create strategy for symbol %s: %w", symbol, err)
 			}
@@ -98,17 +124,31 @@ func (a *TradingAgent) Run(ctx context.Context) error {
 				}()
 			}
 
-			agg := createBarsAggregator(cfg.AggregateBars)
-			if err = prefetchBars(ctx, a.bars, agg, asset, cfg.Prefetch); err != nil {
+			agg, err := createBarsAggregator(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to create bars pipeline for symbol %s: %w", symbol, err)
+			}
+
+			prefetched, err := prefetchBars(ctx, a.bars, symbol, cfg.Prefetch)
+			if err != nil {
 				return fmt.Errorf("failed to prefetch bars for symbol %s: %w", symbol, err)
 			}
 
-			bars, errs := a.bars.GetBars(ctx, symbol)
-			bars = agg(bars)
+			// prefetched and live bars share one pipeline so the stages see the overlap between them
+			var prefetchEnd time.Time
+			if len(prefetched) > 0 {
+				prefetchEnd = prefetched[len(prefetched)-1].Time
+			}
+
+			live, errs := a.bars.GetBars(ctx, symbol)
+			bars := agg(concatBars(ctx, prefetched, live))
 
 			for {
 				select {
 				case <-ctx.Done():
+					if err := s.Finish(ctx, false); err != nil {
+						return errors.Join(ctx.Err(), fmt.Errorf("failed to finish trading strategy for %s: %w", symbol, err))
+					}
 					return ctx.Err()
 				case err, ok := <-errs:
 					if ok {
@@ -116,9 +156,23 @@ func (a *TradingAgent) Run(ctx context.Context) error {
 					}
 				case bar, ok := <-bars:
 					if !ok {
+						if err := s.Finish(ctx, a.closePositions()); err != nil {
+							return fmt.Errorf("failed to finish trading strategy for %s: %w", symbol, err)
+						}
 						return nil
 					}
 
+					if !prefetchEnd.IsZero() && !bar.Time.After(prefetchEnd) {
+						asset.Receive(bar)
+						continue
+					}
+
+					if err := a.clock.Wait(ctx, symbol, bar.Time); err != nil {
+						return err
+					}
+
+					a.report.SubmitPrice(symbol, bar.Time, bar.Close)
+
 					if err := barsDump.Dump(bar); err != nil {
 						return fmt.Errorf("failed to dump bar for symbol %s: %w", symbol, err)
 					}
@@ -128,12 +182,23 @@ func (a *TradingAgent) Run(ctx context.Context) error {
 					if err := s.Run(ctx); err != nil {
 						return fmt.Errorf("failed to run strategy for %s: %w", symbol, err)
 					}
+
+					a.clock.Done(symbol)
 				}
 			}
 		})
 	}
 
-	if err := grp.Wait(); err != nil {
+	if symbol := a.cfg.Benchmark.Symbol; symbol != "" {
+		if _, ok := a.cfg.Strategies[symbol]; !ok {
+			grp.Go(func() error {
+				return a.runBenchmark(ctx, symbol)
+			})
+		}
+	}
+
+	err = grp.Wait()
+	if err != nil && !errors.Is(err, context.Canceled) {
 		return err
 	}
 
@@ -141,9 +206,44 @@ func (a *TradingAgent) Run(ctx context.Context) error {
 		return fmt.Errorf("failed to save report: %w", err)
 	}
 
+	return err
+}
+
+func (a *TradingAgent) closePositions() bool {
+	emu, ok := a.cfg.PlatformRef.Platform.(config.Emulator)
+	return ok && emu.ClosePositions
+}
+
+func (a *TradingAgent) runBenchmark(ctx context.Context, symbol string) error {
+	bars, errs := a.bars.GetBars(ctx, symbol)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err, ok := <-errs:
+			if ok {
+				return fmt.Errorf("error reading benchmark bars for %s: %w", symbol, err)
+			}
+		case bar, ok := <-bars:
+			if !ok {
+				return nil
+			}
+
+			a.report.SubmitPrice(symbol, bar.Time, bar.Close)
+		}
+	}
+}
+
+type noClock struct{}
+
+func (noClock) Wait(_ context.Context, _ string, _ time.Time) error {
 	return nil
 }
 
+func (noClock) Done(_ string) {}
+
+func (noClock) Leave(_ string) {}
+
 func createBarsDump(path string) (*csvBarsDump, io.Closer, error) {
 	if path == "" {
 		return newCsvBarsDump(io.Discard), nil, nil
@@ -161,34 +261,143 @@ func createBarsDump(path string) (*csvBarsDump, io.Closer, error) {
 	return newCsvBarsDump(f), f, nil
 }
 
-func createBarsAggregator(n int) market.BarAggregator {
-	if n > 1 {
-		return market.IntervalAggregator(1*time.Minute, time.Duration(n)*time.Minute)
+const defaultOutlierReanchor = 5
+
+func createBarsAggregator(cfg config.Strategy) (market.BarAggregator, error) {
+	var stages []market.BarAggregator
+	p := cfg.Pipeline
+	if p.Dedupe {
+		stages = append(stages, market.DedupeAggregator())
+	}
+
+	if p.DropInvalid {
+		stages = append(stages, market.InvalidBarFilter())
+	}
+
+	if p.Outliers != nil {
+		reanchor := p.Outliers.Reanchor
+		if reanchor == 0 {
+			reanchor = defaultOutlierReanchor
+		}
+
+		switch p.Outliers.Action {
+		case "", "drop":
+			stages = append(stages, market.OutlierFilter(p.Outliers.MaxReturn, false, reanchor))
+		case "clip":
+			stages = append(stages, market.OutlierFilter(p.Outliers.MaxReturn, true, reanchor))
+		default:
+			return nil, fmt.Errorf("unknown outlier action: %s", p.Outliers.Action)
+		}
+	}
+
+	if p.FillGaps > 0 {
+		stages = append(stages, market.GapFillAggregator(p.FillGaps, p.MaxFill))
+	}
+
+	if cfg.Bars != nil {
+		if cfg.AggregateBars > 1 {
+			return nil, errors.New("aggregate_bars and bars can not be used together")
+		}
+
+		agg, err := createBarTypeAggregator(*cfg.Bars)
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, agg)
+	} else if cfg.AggregateBars > 1 {
+		stages = append(stages, market.IntervalAggregator(1*time.Minute, time.Duration(cfg.AggregateBars)*time.Minute))
+	}
+
+	if len(stages) == 0 {
+		return market.IndentityAggregator(), nil
+	}
+
+	return market.ChainAggregators(stages...), nil
+}
+
+func createBarTypeAggregator(cfg config.BarType) (market.BarAggregator, error) {
+	if cfg.Type == "time" {
+		if cfg.Interval < time.Minute {
+			return nil, fmt.Errorf("time bars interval must be at least 1m, got %s", cfg.Interval)
+		}
+		return market.IntervalAggregator(1*time.Minute, cfg.Interval), nil
+	}
+
+	if cfg.Size <= 0 {
+		return nil, fmt.Errorf("%s bars size must be positive", cfg.Type)
 	}
 
-	return market.IndentityAggregator()
+	size := decimal.NewFromFloat(cfg.Size)
+	switch cfg.Type {
+	case "tick":
+		if cfg.Size < 1 || cfg.Size != math.Trunc(cfg.Size) {
+			return nil, fmt.Errorf("tick bars size must be a whole number of at least 1, got %g", cfg.Size)
+		}
+		return market.TickAggregator(int(cfg.Size)), nil
+	case "volume":
+		return market.VolumeAggregator(size), nil
+	case "dollar":
+		return market.DollarAggregator(size), nil
+	case "range":
+		return market.RangeAggregator(size), nil
+	case "renko":
+		return market.RenkoAggregator(size), nil
+	default:
+		return nil, fmt.Errorf("unknown bars type: %s", cfg.Type)
+	}
 }
 
-func prefetchBars(ctx context.Context, bars barsSource, agg market.BarAggregator, asset *market.Asset, n int) error {
+func prefetchBars(ctx context.Context, bars barsSource, symbol string, n int) ([]market.Bar, error) {
 	if n < 1 {
-		return nil
+		return nil, nil
 	}
 
-	barsCh, err := bars.Prefetch(asset.Symbol, n)
+	barsCh, err := bars.Prefetch(symbol, n)
 	if err != nil {
-		return fmt.Errorf("failed to prefetch last %d bars for symbol %s: %w", n, asset.Symbol, err)
+		return nil, fmt.Errorf("failed to prefetch last %d bars for symbol %s: %w", n, symbol, err)
 	}
 
-	for b := range agg(barsCh) {
+	prefetched := make([]market.Bar, 0, n)
+	for b := range barsCh {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return nil, ctx.Err()
 		default:
-			asset.Receive(b)
+			prefetched = append(prefetched, b)
 		}
 	}
 
-	return nil
+	return prefetched, nil
+}
+
+func concatBars(ctx context.Context, first []market.Bar, rest <-chan market.Bar) <-chan market.Bar {
+	out := make(chan market.Bar)
+	go func() {
+		defer close(out)
+
+		send := func(b market.Bar) bool {
+			select {
+			case out <- b:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for _, b := range first {
+			if !send(b) {
+				return
+			}
+		}
+
+		for b := range rest {
+			if !send(b) {
+				return
+			}
+		}
+	}()
+
+	return out
 }
 
 func (a *TradingAgent) saveReport() (err error) {
@@ -206,5 +415,10 @@ func (a *TradingAgent) saveReport() (err error) {
 		return fmt.Errorf("failed to write report: %w", err)
 	}
 
+	prefix := strings.TrimSuffix(a.cfg.Report, filepath.Ext(a.cfg.Report)) + ".equity"
+	if err := a.report.WriteEquity(prefix); err != nil {
+		return fmt.Errorf("failed to write equity curve: %w", err)
+	}
+
 	return nil
 }
@@ -3,23 +3,37 @@ package agent
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/gamma-omg/trading-bot/internal/config"
 	"github.com/gamma-omg/trading-bot/internal/indicator"
 	"github.com/gamma-omg/trading-bot/internal/market"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type mockBarsSource struct {
-	bars chan market.Bar
-	errs chan error
+	prefetch []market.Bar
+	bars     chan market.Bar
+	errs     chan error
 }
 
 func (m *mockBarsSource) Prefetch(symbol string, count int) (<-chan market.Bar, error) {
-	return nil, errors.New("not supported")
+	if m.prefetch == nil {
+		return nil, errors.New("not supported")
+	}
+
+	out := make(chan market.Bar, len(m.prefetch))
+	for _, b := range m.prefetch {
+		out <- b
+	}
+	close(out)
+	return out, nil
 }
 
 func (m *mockBarsSource) GetBars(ctx context.Context, symbol string) (<-chan market.Bar, <-chan error) {
@@ -27,7 +41,8 @@ func (m *mockBarsSource) GetBars(ctx context.Context, symbol string) (<-chan mar
 }
 
 type mockTradingStrategy struct {
-	runCalls int
+	runCalls    int
+	finishCalls int
 }
 
 func (m *mockTradingStrategy) Init() error {
@@ -39,6 +54,11 @@ func (m *mockTradingStrategy) Run(ctx context.Context) error {
 	return nil
 }
 
+func (m *mockTradingStrategy) Finish(_ context.Context, _ bool) error {
+	m.finishCalls++
+	return nil
+}
+
 func TestCreateIndicator_MACD(t *testing.T) {
 	ind, err := createIndicator(config.IndicatorReference{
 		Indicator: config.MACD{
@@ -51,7 +71,7 @@ func TestCreateIndicator_MACD(t *testing.T) {
 			SellCap:       1000,
 			CrossLookback: 3,
 		},
-	}, market.NewAsset("BTC", 1))
+	}, market.NewAsset("BTC", 1), time.Minute)
 
 	assert.NoError(t, err)
 	assert.IsType(t, &indicator.MACDIndicator{}, ind)
@@ -73,7 +93,7 @@ func TestCreateIndicator_Ensemble(t *testing.T) {
 				},
 			},
 		},
-	}, market.NewAsset("BTC", 1))
+	}, market.NewAsset("BTC", 1), time.Minute)
 
 	assert.NoError(t, err)
 	assert.IsType(t, &indicator.EnsembleIndicator{}, ind)
@@ -86,10 +106,43 @@ func TestCreateIndicator_Ensemble(t *testing.T) {
 	assert.IsType(t, &indicator.MACDIndicator{}, e.Children[1].Indicator)
 }
 
+func TestCreateIndicator_Transform(t *testing.T) {
+	ind, err := createIndicator(config.IndicatorReference{
+		Indicator: config.Ensemble{
+			{Weight: 1, IndRef: config.IndicatorReference{Indicator: config.MACD{Transform: "heikin_ashi"}}},
+			{Weight: 1, IndRef: config.IndicatorReference{Indicator: config.RSI{}}},
+		},
+	}, market.NewAsset("BTC", 1), time.Minute)
+	assert.NoError(t, err)
+	assert.IsType(t, &indicator.EnsembleIndicator{}, ind)
+
+	_, err = createIndicator(config.IndicatorReference{
+		Indicator: config.RSI{Transform: "unknown"},
+	}, market.NewAsset("BTC", 1), time.Minute)
+	assert.Error(t, err)
+}
+
+func TestCreateIndicator_Timeframe(t *testing.T) {
+	ind, err := createIndicator(config.IndicatorReference{
+		Indicator: config.RSI{Timeframe: time.Hour},
+	}, market.NewAsset("BTC", 1), 5*time.Minute)
+	assert.NoError(t, err)
+	assert.IsType(t, &indicator.TimeframeIndicator{}, ind)
+
+	_, err = createIndicator(config.IndicatorReference{
+		Indicator: config.MACD{Timeframe: time.Minute},
+	}, market.NewAsset("BTC", 1), 5*time.Minute)
+	assert.Error(t, err)
+
+	assert.Equal(t, 5*time.Minute, config.Strategy{AggregateBars: 5}.BarDuration())
+	assert.Equal(t, time.Minute, config.Strategy{}.BarDuration())
+	assert.Equal(t, time.Duration(0), config.Strategy{Bars: &config.BarType{Type: "volume"}}.BarDuration())
+}
+
 func TestCreateIndicator_InvalidType(t *testing.T) {
 	ind, err := createIndicator(config.IndicatorReference{
 		Indicator: "invalid",
-	}, market.NewAsset("BTC", 1))
+	}, market.NewAsset("BTC", 1), time.Minute)
 
 	assert.Error(t, err)
 	assert.Nil(t, ind)
@@ -98,7 +151,7 @@ func TestCreateIndicator_InvalidType(t *testing.T) {
 func TestCreateIndicator_EmptyEnsemble(t *testing.T) {
 	ind, err := createIndicator(config.IndicatorReference{
 		Indicator: config.Ensemble{},
-	}, market.NewAsset("BTC", 1))
+	}, market.NewAsset("BTC", 1), time.Minute)
 
 	assert.NoError(t, err)
 	assert.IsType(t, &indicator.EnsembleIndicator{}, ind)
@@ -117,9 +170,11 @@ func TestAgentRun(t *testing.T) {
 	}
 	str := mockTradingStrategy{}
 	a := TradingAgent{
-		log:  slog.New(slog.DiscardHandler),
-		bars: &src,
-		strategyFactory: func(cfg config.Strategy, asset *market.Asset) (tradingStrategy, error) {
+		log:    slog.New(slog.DiscardHandler),
+		bars:   &src,
+		clock:  noClock{},
+		report: &mockReport{},
+		strategyFactory: func(cfg config.Strategy, asset *market.Asset, _ tradingJournal) (tradingStrategy, error) {
 			return &str, nil
 		},
 		cfg: config.Config{
@@ -147,4 +202,167 @@ func TestAgentRun(t *testing.T) {
 	}
 
 	assert.Equal(t, 3, str.runCalls)
+	assert.Equal(t, 1, str.finishCalls)
+}
+
+func TestAgentRun_prefetchPipeline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	bar := func(sec int64) market.Bar {
+		p := decimal.NewFromInt(100)
+		return market.Bar{Time: time.Unix(sec, 0), Open: p, High: p, Low: p, Close: p}
+	}
+
+	src := mockBarsSource{
+		prefetch: []market.Bar{bar(0), bar(60)},
+		bars:     make(chan market.Bar, 3),
+		errs:     make(chan error, 1),
+	}
+	src.bars <- bar(60)
+	src.bars <- bar(240)
+	close(src.bars)
+
+	str := mockTradingStrategy{}
+	var asset *market.Asset
+	a := TradingAgent{
+		log:    slog.New(slog.DiscardHandler),
+		bars:   &src,
+		clock:  noClock{},
+		report: &mockReport{},
+		strategyFactory: func(cfg config.Strategy, a *market.Asset, _ tradingJournal) (tradingStrategy, error) {
+			asset = a
+			return &str, nil
+		},
+		cfg: config.Config{
+			Report: filepath.Join(t.TempDir(), "report.json"),
+			Strategies: map[string]config.Strategy{
+				"BTC": {
+					MarketBuffer: 10,
+					Prefetch:     2,
+					Pipeline:     config.BarPipeline{Dedupe: true, FillGaps: time.Minute},
+				},
+			},
+		},
+	}
+
+	require.NoError(t, a.Run(ctx))
+
+	bars, err := asset.GetBars(5)
+	require.NoError(t, err)
+	for i, b := range bars {
+		assert.Equal(t, time.Unix(int64(i)*60, 0), b.Time)
+	}
+	assert.Equal(t, 3, str.runCalls)
+}
+
+type recordingClock struct {
+	noClock
+	events *[]string
+}
+
+func (c recordingClock) Wait(_ context.Context, _ string, t time.Time) error {
+	*c.events = append(*c.events, fmt.Sprintf("wait %d", t.Unix()))
+	return nil
+}
+
+type recordingReport struct {
+	mockReport
+	events *[]string
+}
+
+func (r *recordingReport) SubmitPrice(_ string, t time.Time, _ decimal.Decimal) {
+	*r.events = append(*r.events, fmt.Sprintf("price %d", t.Unix()))
+}
+
+func TestAgentRun_submitPriceAfterClockWait(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	src := mockBarsSource{
+		bars: make(chan market.Bar, 2),
+		errs: make(chan error, 1),
+	}
+	src.bars <- market.Bar{Time: time.Unix(60, 0)}
+	src.bars <- market.Bar{Time: time.Unix(120, 0)}
+	close(src.bars)
+
+	var events []string
+	a := TradingAgent{
+		log:    slog.New(slog.DiscardHandler),
+		bars:   &src,
+		clock:  recordingClock{events: &events},
+		report: &recordingReport{events: &events},
+		strategyFactory: func(cfg config.Strategy, asset *market.Asset, _ tradingJournal) (tradingStrategy, error) {
+			return &mockTradingStrategy{}, nil
+		},
+		cfg: config.Config{
+			Report:     filepath.Join(t.TempDir(), "report.json"),
+			Strategies: map[string]config.Strategy{"BTC": {MarketBuffer: 1}},
+		},
+	}
+
+	require.NoError(t, a.Run(ctx))
+	assert.Equal(t, []string{"wait 60", "price 60", "wait 120", "price 120"}, events)
+}
+
+func TestCreateBarsAggregator(t *testing.T) {
+	agg, err := createBarsAggregator(config.Strategy{
+		AggregateBars: 2,
+		Pipeline: config.BarPipeline{
+			Dedupe:   true,
+			FillGaps: time.Minute,
+			Outliers: &config.Outliers{MaxReturn: 0.5},
+		},
+	})
+	require.NoError(t, err)
+
+	in := make(chan market.Bar, 5)
+	for _, b := range []struct {
+		t int64
+		c int64
+	}{{0, 10}, {0, 10}, {60, 100}, {180, 12}, {240, 11}} {
+		p := decimal.NewFromInt(b.c)
+		in <- market.Bar{Time: time.Unix(b.t, 0), Open: p, High: p, Low: p, Close: p, Volume: decimal.NewFromInt(1)}
+	}
+	close(in)
+
+	var out []string
+	for b := range agg(in) {
+		out = append(out, fmt.Sprintf("%d:%s:%s", b.Time.Unix(), b.Close, b.Volume))
+	}
+	assert.Equal(t, []string{"0:10:1", "120:12:1", "240:11:1"}, out)
+
+	_, err = createBarsAggregator(config.Strategy{Pipeline: config.BarPipeline{Outliers: &config.Outliers{Action: "smooth"}}})
+	assert.Error(t, err)
+}
+
+func TestCreateBarTypeAggregator(t *testing.T) {
+	valid := []config.BarType{
+		{Type: "time", Interval: 5 * time.Minute},
+		{Type: "tick", Size: 10},
+		{Type: "volume", Size: 1.5},
+		{Type: "dollar", Size: 100000},
+		{Type: "range", Size: 50},
+		{Type: "renko", Size: 25},
+	}
+	for _, c := range valid {
+		agg, err := createBarTypeAggregator(c)
+		assert.NoError(t, err, c.Type)
+		assert.NotNil(t, agg, c.Type)
+	}
+
+	invalid := []config.BarType{
+		{Type: "time", Interval: time.Second},
+		{Type: "volume"},
+		{Type: "heikin", Size: 1},
+		{Type: "tick", Size: 0.5},
+	}
+	for _, c := range invalid {
+		_, err := createBarTypeAggregator(c)
+		assert.Error(t, err, c.Type)
+	}
+
+	_, err := createBarsAggregator(config.Strategy{AggregateBars: 5, Bars: &config.BarType{Type: "tick", Size: 5}})
+	assert.Error(t, err)
 }
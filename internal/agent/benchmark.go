@@ -0,0 +1,204 @@
+package agent
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type JsonBenchmark struct {
+	Symbol         string   `json:"symbol"`
+	Return         float64  `json:"return"`
+	StrategyReturn float64  `json:"strategy_return"`
+	ExcessReturn   float64  `json:"excess_return"`
+	Alpha          *float64 `json:"alpha,omitempty"`
+	Beta           *float64 `json:"beta,omitempty"`
+	Correlation    *float64 `json:"correlation,omitempty"`
+}
+
+type benchmarkSeries struct {
+	budget   float64
+	first    EquityPoint
+	prices   []EquityPoint
+	equity   []EquityPoint
+	buyComm  float64
+	sellComm float64
+}
+
+type benchmarkGrid struct {
+	period   time.Duration
+	times    []time.Time
+	strategy []float64
+	bench    []float64
+}
+
+func newBenchmarkGrid(period time.Duration, series []benchmarkSeries) *benchmarkGrid {
+	var times []time.Time
+	for _, s := range series {
+		for _, p := range s.prices {
+			times = append(times, p.Time.Truncate(period))
+		}
+		for _, p := range s.equity {
+			times = append(times, p.Time.Truncate(period))
+		}
+	}
+	if len(times) == 0 {
+		return nil
+	}
+
+	slices.SortFunc(times, func(a, b time.Time) int {
+		return a.Compare(b)
+	})
+	times = slices.CompactFunc(times, func(a, b time.Time) bool {
+		return a.Equal(b)
+	})
+
+	g := &benchmarkGrid{
+		period:   period,
+		times:    times,
+		strategy: make([]float64, len(times)+1),
+		bench:    make([]float64, len(times)+1),
+	}
+	for _, s := range series {
+		g.add(s)
+	}
+
+	return g
+}
+
+func (g *benchmarkGrid) add(s benchmarkSeries) {
+	p0, _ := s.first.Equity.Float64()
+	g.strategy[0] += s.budget
+	g.bench[0] += s.budget
+
+	var pi, ei int
+	strategy, bench := s.budget, s.budget
+	for i, t := range g.times {
+		for ; pi < len(s.prices) && !s.prices[pi].Time.Truncate(g.period).After(t); pi++ {
+			p, _ := s.prices[pi].Equity.Float64()
+			bench = s.budget * (1 - s.buyComm) * p / p0
+		}
+		for ; ei < len(s.equity) && !s.equity[ei].Time.Truncate(g.period).After(t); ei++ {
+			strategy, _ = s.equity[ei].Equity.Float64()
+		}
+
+		g.strategy[i+1] += strategy
+		g.bench[i+1] += bench
+	}
+
+	g.bench[len(g.bench)-1] -= bench * s.sellComm
+}
+
+func (g *benchmarkGrid) compare(symbol string, riskFreeRate float64) *JsonBenchmark {
+	n := len(g.strategy) - 1
+	b := &JsonBenchmark{
+		Symbol:         symbol,
+		Return:         g.bench[n]/g.bench[0] - 1,
+		StrategyReturn: g.strategy[n]/g.strategy[0] - 1,
+	}
+	b.ExcessReturn = b.StrategyReturn - b.Return
+
+	rs := periodReturns(g.strategy)
+	rb := periodReturns(g.bench)
+	if len(rs) < 2 {
+		return b
+	}
+
+	ms, mb := mean(rs), mean(rb)
+	var cov, vs, vb float64
+	for i := range rs {
+		cov += (rs[i] - ms) * (rb[i] - mb)
+		vs += (rs[i] - ms) * (rs[i] - ms)
+		vb += (rb[i] - mb) * (rb[i] - mb)
+	}
+
+	if vb > 0 {
+		beta := cov / vb
+		rf := riskFreeRate * float64(g.period) / float64(tradingYear)
+		alpha := (ms - rf - beta*(mb-rf)) * float64(tradingYear) / float64(g.period)
+		b.Beta = &beta
+		b.Alpha = &alpha
+	}
+	if vs > 0 && vb > 0 {
+		corr := cov / math.Sqrt(vs*vb)
+		b.Correlation = &corr
+	}
+
+	return b
+}
+
+func periodReturns(equity []float64) []float64 {
+	returns := make([]float64, 0, len(equity)-1)
+	for i := 1; i < len(equity); i++ {
+		if equity[i-1] <= 0 {
+			return returns
+		}
+		returns = append(returns, equity[i]/equity[i-1]-1)
+	}
+
+	return returns
+}
+
+func mean(v []float64) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += x
+	}
+
+	return sum / float64(len(v))
+}
+
+func (r *JsonReportBuilder) buildBenchmarks() {
+	period := returnPeriod(r.cfg)
+
+	var all []benchmarkSeries
+	benchmarks := map[string]*JsonBenchmark{}
+	for symbol, budget := range r.budgets {
+		s, ok := r.benchmarkSeries(symbol, budget)
+		if !ok {
+			continue
+		}
+
+		if g := newBenchmarkGrid(period, []benchmarkSeries{s}); g != nil {
+			benchmarks[symbol] = g.compare(r.benchmarkSymbol(symbol), r.cfg.RiskFreeRate)
+			all = append(all, s)
+		}
+	}
+
+	if len(benchmarks) == 0 {
+		return
+	}
+
+	r.report.Benchmarks = benchmarks
+	if g := newBenchmarkGrid(period, all); g != nil {
+		r.report.Benchmark = g.compare(r.benchmark.symbol, r.cfg.RiskFreeRate)
+	}
+}
+
+func (r *JsonReportBuilder) benchmarkSymbol(symbol string) string {
+	if r.benchmark.symbol != "" {
+		return r.benchmark.symbol
+	}
+
+	return symbol
+}
+
+func (r *JsonReportBuilder) benchmarkSeries(symbol string, budget decimal.Decimal) (benchmarkSeries, bool) {
+	b, _ := budget.Float64()
+	bench := r.benchmarkSymbol(symbol)
+	first, ok := r.benchmark.first[bench]
+	if b <= 0 || !ok || !first.Equity.IsPositive() {
+		return benchmarkSeries{}, false
+	}
+
+	return benchmarkSeries{
+		budget:   b,
+		first:    first,
+		prices:   r.benchmark.prices.Series(bench),
+		equity:   r.benchmark.equity.Series(symbol),
+		buyComm:  r.benchmark.buyCommission,
+		sellComm: r.benchmark.sellCommission,
+	}, true
+}
@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func day(n int) time.Time {
+	return time.Date(2024, 1, 1+n, 12, 0, 0, 0, time.UTC)
+}
+
+func TestBuildBenchmarks(t *testing.T) {
+	r := NewJsonReportBuilder(slog.New(slog.DiscardHandler), config.Config{
+		Strategies: map[string]config.Strategy{"BTC": {Budget: 1000}},
+		PlatformRef: config.PlatformReference{Platform: config.Emulator{
+			BuyCommission:  0.01,
+			SellCommission: 0.01,
+		}},
+	})
+
+	for i, c := range []struct{ price, equity int64 }{{100, 1000}, {110, 1050}, {121, 1100}} {
+		r.SubmitPrice("BTC", day(i), decimal.NewFromInt(c.price))
+		r.SubmitEquity("BTC", day(i), decimal.NewFromInt(c.equity))
+	}
+
+	report := r.build()
+	require.Contains(t, report.Benchmarks, "BTC")
+	require.NotNil(t, report.Benchmark)
+
+	b := report.Benchmarks["BTC"]
+	assert.Equal(t, "BTC", b.Symbol)
+	assert.InDelta(t, 0.185921, b.Return, 1e-9)
+	assert.InDelta(t, 0.1, b.StrategyReturn, 1e-9)
+	assert.InDelta(t, -0.085921, b.ExcessReturn, 1e-9)
+	require.NotNil(t, b.Beta)
+	require.NotNil(t, b.Alpha)
+	require.NotNil(t, b.Correlation)
+	assert.Positive(t, *b.Correlation)
+
+	assert.Equal(t, b.Return, report.Benchmark.Return)
+	assert.Equal(t, b.StrategyReturn, report.Benchmark.StrategyReturn)
+}
+
+func TestBuildBenchmarks_customSymbol(t *testing.T) {
+	r := NewJsonReportBuilder(slog.New(slog.DiscardHandler), config.Config{
+		Strategies: map[string]config.Strategy{
+			"BTC": {Budget: 1000},
+			"ETH": {Budget: 3000},
+		},
+		Benchmark: config.Benchmark{Symbol: "SPY"},
+	})
+
+	for i, price := range []int64{100, 90, 120} {
+		r.SubmitPrice("SPY", day(i), decimal.NewFromInt(price))
+		r.SubmitPrice("BTC", day(i), decimal.NewFromInt(1))
+		r.SubmitEquity("BTC", day(i), decimal.NewFromInt(1000))
+		r.SubmitEquity("ETH", day(i), decimal.NewFromInt(3000+int64(i)*300))
+	}
+
+	report := r.build()
+	require.Len(t, report.Benchmarks, 2)
+	assert.Equal(t, "SPY", report.Benchmarks["BTC"].Symbol)
+	assert.InDelta(t, 0.2, report.Benchmarks["BTC"].Return, 1e-9)
+	assert.InDelta(t, 0, report.Benchmarks["BTC"].StrategyReturn, 1e-9)
+	assert.InDelta(t, 0.2, report.Benchmarks["ETH"].StrategyReturn, 1e-9)
+
+	assert.Equal(t, "SPY", report.Benchmark.Symbol)
+	assert.InDelta(t, 0.2, report.Benchmark.Return, 1e-9)
+	assert.InDelta(t, 0.15, report.Benchmark.StrategyReturn, 1e-9)
+	assert.InDelta(t, -0.05, report.Benchmark.ExcessReturn, 1e-9)
+}
+
+func TestBuildBenchmarks_noPrices(t *testing.T) {
+	r := NewJsonReportBuilder(slog.New(slog.DiscardHandler), config.Config{
+		Strategies: map[string]config.Strategy{"BTC": {Budget: 1000}},
+	})
+	r.SubmitEquity("BTC", day(0), decimal.NewFromInt(1000))
+
+	report := r.build()
+	assert.Nil(t, report.Benchmark)
+	assert.Nil(t, report.Benchmarks)
+}
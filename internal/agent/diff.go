@@ -0,0 +1,378 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	DiffDivergenceBaseOnly  = "only_in_base"
+	DiffDivergenceOtherOnly = "only_in_other"
+	DiffDivergenceExit      = "different_exit"
+	DiffDivergenceGain      = "different_gain"
+)
+
+type ReportDiff struct {
+	Base       string           `json:"base"`
+	Other      string           `json:"other"`
+	Metrics    []MetricDelta    `json:"metrics"`
+	Symbols    []SymbolDiff     `json:"symbols"`
+	Matched    []MatchedTrade   `json:"matched"`
+	Unmatched  []UnmatchedTrade `json:"unmatched"`
+	Divergence *Divergence      `json:"divergence,omitempty"`
+}
+
+type MetricDelta struct {
+	Name  string   `json:"name"`
+	Base  *float64 `json:"base"`
+	Other *float64 `json:"other"`
+	Delta *float64 `json:"delta"`
+}
+
+type SymbolDiff struct {
+	Symbol      string  `json:"symbol"`
+	BaseTrades  int     `json:"base_trades"`
+	OtherTrades int     `json:"other_trades"`
+	BaseGain    float64 `json:"base_gain"`
+	OtherGain   float64 `json:"other_gain"`
+	Delta       float64 `json:"delta"`
+}
+
+type MatchedTrade struct {
+	Symbol        string    `json:"symbol"`
+	BuyTime       time.Time `json:"buy_time"`
+	BaseSellTime  time.Time `json:"base_sell_time"`
+	OtherSellTime time.Time `json:"other_sell_time"`
+	BaseGain      float64   `json:"base_gain"`
+	OtherGain     float64   `json:"other_gain"`
+	Delta         float64   `json:"delta"`
+}
+
+type UnmatchedTrade struct {
+	Symbol   string    `json:"symbol"`
+	Report   string    `json:"report"`
+	BuyTime  time.Time `json:"buy_time"`
+	SellTime time.Time `json:"sell_time"`
+	Gain     float64   `json:"gain"`
+}
+
+type Divergence struct {
+	Symbol string    `json:"symbol"`
+	Time   time.Time `json:"time"`
+	Reason string    `json:"reason"`
+}
+
+func ReadJsonReport(path string) (JsonReport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return JsonReport{}, fmt.Errorf("failed to open report %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var r JsonReport
+	if err := json.NewDecoder(f).Decode(&r); err != nil {
+		return JsonReport{}, fmt.Errorf("failed to parse report %s: %w", path, err)
+	}
+
+	return r, nil
+}
+
+func DiffReports(baseName string, base JsonReport, otherName string, other JsonReport) ReportDiff {
+	d := ReportDiff{
+		Base:      baseName,
+		Other:     otherName,
+		Matched:   []MatchedTrade{},
+		Unmatched: []UnmatchedTrade{},
+	}
+
+	baseMetrics := ReportMetrics(base)
+	otherMetrics := ReportMetrics(other)
+	for _, name := range ReportMetricNames {
+		m := MetricDelta{Name: name, Base: baseMetrics[name], Other: otherMetrics[name]}
+		if m.Base != nil && m.Other != nil {
+			delta := *m.Other - *m.Base
+			m.Delta = &delta
+		}
+		d.Metrics = append(d.Metrics, m)
+	}
+
+	symbols := slices.Collect(maps.Keys(base.Deals))
+	for symbol := range other.Deals {
+		if _, ok := base.Deals[symbol]; !ok {
+			symbols = append(symbols, symbol)
+		}
+	}
+	slices.Sort(symbols)
+
+	for _, symbol := range symbols {
+		d.diffSymbol(symbol, base.Deals[symbol], other.Deals[symbol])
+	}
+
+	return d
+}
+
+func (d *ReportDiff) diffSymbol(symbol string, base, other []JsonDeal) {
+	s := SymbolDiff{
+		Symbol:      symbol,
+		BaseTrades:  len(base),
+		OtherTrades: len(other),
+	}
+
+	matched := make(map[int64]JsonDeal, len(other))
+	for _, o := range other {
+		matched[o.BuyTime.UnixNano()] = o
+		s.OtherGain += parseFloat(o.Gain)
+	}
+
+	for _, b := range base {
+		s.BaseGain += parseFloat(b.Gain)
+
+		o, ok := matched[b.BuyTime.UnixNano()]
+		if !ok {
+			d.unmatched(symbol, d.Base, b, DiffDivergenceBaseOnly)
+			continue
+		}
+		delete(matched, b.BuyTime.UnixNano())
+
+		m := MatchedTrade{
+			Symbol:        symbol,
+			BuyTime:       b.BuyTime,
+			BaseSellTime:  b.SellTime,
+			OtherSellTime: o.SellTime,
+			BaseGain:      parseFloat(b.Gain),
+			OtherGain:     parseFloat(o.Gain),
+		}
+		m.Delta = m.OtherGain - m.BaseGain
+		d.Matched = append(d.Matched, m)
+
+		switch {
+		case !m.BaseSellTime.Equal(m.OtherSellTime):
+			exit := m.BaseSellTime
+			if m.OtherSellTime.Before(exit) {
+				exit = m.OtherSellTime
+			}
+			d.diverge(symbol, exit, DiffDivergenceExit)
+		case m.Delta != 0:
+			d.diverge(symbol, m.BaseSellTime, DiffDivergenceGain)
+		}
+	}
+
+	for _, o := range other {
+		if _, ok := matched[o.BuyTime.UnixNano()]; ok {
+			d.unmatched(symbol, d.Other, o, DiffDivergenceOtherOnly)
+		}
+	}
+
+	s.Delta = s.OtherGain - s.BaseGain
+	d.Symbols = append(d.Symbols, s)
+}
+
+func (d *ReportDiff) unmatched(symbol, report string, deal JsonDeal, reason string) {
+	d.Unmatched = append(d.Unmatched, UnmatchedTrade{
+		Symbol:   symbol,
+		Report:   report,
+		BuyTime:  deal.BuyTime,
+		SellTime: deal.SellTime,
+		Gain:     parseFloat(deal.Gain),
+	})
+	d.diverge(symbol, deal.BuyTime, reason)
+}
+
+func (d *ReportDiff) diverge(symbol string, t time.Time, reason string) {
+	if d.Divergence != nil && !t.Before(d.Divergence.Time) {
+		return
+	}
+
+	d.Divergence = &Divergence{Symbol: symbol, Time: t, Reason: reason}
+}
+
+func (d ReportDiff) Equal() bool {
+	if d.Divergence != nil || len(d.Unmatched) > 0 {
+		return false
+	}
+
+	for _, m := range d.Metrics {
+		if (m.Base == nil) != (m.Other == nil) || (m.Delta != nil && *m.Delta != 0) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func WriteDiffTable(w io.Writer, d ReportDiff) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "base: %s\tother: %s\t\n\n", d.Base, d.Other)
+
+	fmt.Fprintln(tw, "metric\tbase\tother\tdelta\t")
+	for _, m := range d.Metrics {
+		if m.Base == nil && m.Other == nil {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t\n", m.Name, formatOptional(m.Base), formatOptional(m.Other), formatOptional(m.Delta))
+	}
+
+	fmt.Fprintln(tw, "\nsymbol\tbase trades\tother trades\tbase gain\tother gain\tdelta\t")
+	for _, s := range d.Symbols {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.6g\t%.6g\t%.6g\t\n", s.Symbol, s.BaseTrades, s.OtherTrades, s.BaseGain, s.OtherGain, s.Delta)
+	}
+
+	fmt.Fprintf(tw, "\nmatched trades: %d\tunmatched trades: %d\t\n", len(d.Matched), len(d.Unmatched))
+	if len(d.Unmatched) > 0 {
+		fmt.Fprintln(tw, "\nreport\tsymbol\tbuy time\tsell time\tgain\t")
+		for _, u := range d.Unmatched {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%.6g\t\n", u.Report, u.Symbol, u.BuyTime.Format(time.RFC3339), u.SellTime.Format(time.RFC3339), u.Gain)
+		}
+	}
+
+	if d.Divergence != nil {
+		fmt.Fprintf(tw, "\nfirst divergence: %s %s (%s)\t\n", d.Divergence.Symbol, d.Divergence.Time.Format(time.RFC3339), d.Divergence.Reason)
+	} else {
+		fmt.Fprintln(tw, "\nno divergence\t")
+	}
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write report diff: %w", err)
+	}
+
+	return nil
+}
+
+var ReportMetricNames = []string{
+	"total_gain",
+	"total_gain_pct",
+	"unrealized_gain",
+	"trades",
+	"win_rate",
+	"avg_win",
+	"avg_loss",
+	"expectancy",
+	"profit_factor",
+	"max_drawdown",
+	"max_drawdown_duration",
+	"sharpe",
+	"sortino",
+	"exposure",
+	"avg_holding_time",
+	"benchmark_return",
+	"excess_return",
+	"alpha",
+	"beta",
+	"correlation",
+}
+
+func ReportMetrics(r JsonReport) map[string]*float64 {
+	values := map[string]*float64{}
+	set := func(name string, v *float64) {
+		if v != nil {
+			values[name] = v
+		}
+	}
+	setString := func(name string, s string) {
+		if v, err := strconv.ParseFloat(s, 64); err == nil {
+			values[name] = &v
+		}
+	}
+	setDuration := func(name string, s string) {
+		if v, err := time.ParseDuration(s); err == nil {
+			hours := v.Hours()
+			values[name] = &hours
+		}
+	}
+	setFloat := func(name string, v float64) {
+		values[name] = &v
+	}
+
+	setString("total_gain", r.TotalGain)
+	setFloat("total_gain_pct", r.TotalGainPct)
+	setString("unrealized_gain", r.UnrealizedGain)
+
+	if m := r.Metrics; m != nil {
+		setFloat("trades", float64(m.Trades))
+		setFloat("win_rate", m.WinRate)
+		setString("avg_win", m.AvgWin)
+		setString("avg_loss", m.AvgLoss)
+		setString("expectancy", m.Expectancy)
+		set("profit_factor", m.ProfitFactor)
+		setFloat("max_drawdown", m.MaxDrawdown)
+		setDuration("max_drawdown_duration", m.MaxDrawdownDuration)
+		set("sharpe", m.Sharpe)
+		set("sortino", m.Sortino)
+		setFloat("exposure", m.Exposure)
+		setDuration("avg_holding_time", m.AvgHoldingTime)
+	}
+
+	if b := r.Benchmark; b != nil {
+		setFloat("benchmark_return", b.Return)
+		setFloat("excess_return", b.ExcessReturn)
+		set("alpha", b.Alpha)
+		set("beta", b.Beta)
+		set("correlation", b.Correlation)
+	}
+
+	return values
+}
+
+func WriteReportTable(w io.Writer, r JsonReport) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	metrics := ReportMetrics(r)
+	fmt.Fprintln(tw, "metric\tvalue\t")
+	for _, name := range ReportMetricNames {
+		if v, ok := metrics[name]; ok {
+			fmt.Fprintf(tw, "%s\t%s\t\n", name, formatOptional(v))
+		}
+	}
+
+	if len(r.Deals) > 0 {
+		fmt.Fprintln(tw, "\nsymbol\ttrades\twin rate\tgain\t")
+		for _, symbol := range slices.Sorted(maps.Keys(r.Deals)) {
+			var gain float64
+			for _, d := range r.Deals[symbol] {
+				gain += parseFloat(d.Gain)
+			}
+
+			winRate := "-"
+			if m := r.Symbols[symbol]; m != nil {
+				winRate = strconv.FormatFloat(m.WinRate, 'g', 6, 64)
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%.6g\t\n", symbol, len(r.Deals[symbol]), winRate, gain)
+		}
+	}
+
+	if len(r.OpenPositions) > 0 {
+		fmt.Fprintln(tw, "\nopen position\tqty\tentry price\tprice\tgain\t")
+		for _, symbol := range slices.Sorted(maps.Keys(r.OpenPositions)) {
+			p := r.OpenPositions[symbol]
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t\n", symbol, p.Qty, p.EntryPrice, p.Price, p.Gain)
+		}
+	}
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	return nil
+}
+
+func parseFloat(s string) float64 {
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
+
+func formatOptional(v *float64) string {
+	if v == nil || math.IsNaN(*v) {
+		return "-"
+	}
+
+	return strconv.FormatFloat(*v, 'g', 6, 64)
+}
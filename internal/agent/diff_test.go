@@ -0,0 +1,124 @@
+package agent
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffReports(t *testing.T) {
+	base := JsonReport{
+		TotalGain: "30",
+		Metrics:   &JsonMetrics{Trades: 3, WinRate: 1, AvgHoldingTime: "1h0m0s"},
+		Deals: map[string][]JsonDeal{
+			"BTC": {
+				{BuyTime: time.Unix(0, 0), SellTime: time.Unix(60, 0), Gain: "10"},
+				{BuyTime: time.Unix(120, 0), SellTime: time.Unix(180, 0), Gain: "10"},
+			},
+			"ETH": {
+				{BuyTime: time.Unix(300, 0), SellTime: time.Unix(360, 0), Gain: "10"},
+			},
+		},
+	}
+	other := JsonReport{
+		TotalGain: "25",
+		Metrics:   &JsonMetrics{Trades: 3, WinRate: 2.0 / 3, AvgHoldingTime: "2h0m0s"},
+		Deals: map[string][]JsonDeal{
+			"BTC": {
+				{BuyTime: time.Unix(0, 0), SellTime: time.Unix(60, 0), Gain: "10"},
+				{BuyTime: time.Unix(120, 0), SellTime: time.Unix(240, 0), Gain: "20"},
+			},
+			"ETH": {
+				{BuyTime: time.Unix(270, 0), SellTime: time.Unix(360, 0), Gain: "-5"},
+			},
+		},
+	}
+
+	d := DiffReports("a.json", base, "b.json", other)
+	assert.False(t, d.Equal())
+
+	metrics := map[string]MetricDelta{}
+	for _, m := range d.Metrics {
+		metrics[m.Name] = m
+	}
+	require.NotNil(t, metrics["total_gain"].Delta)
+	assert.Equal(t, -5.0, *metrics["total_gain"].Delta)
+	assert.Equal(t, 0.0, *metrics["trades"].Delta)
+	assert.Equal(t, 1.0, *metrics["avg_holding_time"].Delta)
+	assert.Nil(t, metrics["sharpe"].Base)
+	assert.Nil(t, metrics["sharpe"].Delta)
+
+	assert.Equal(t, []SymbolDiff{
+		{Symbol: "BTC", BaseTrades: 2, OtherTrades: 2, BaseGain: 20, OtherGain: 30, Delta: 10},
+		{Symbol: "ETH", BaseTrades: 1, OtherTrades: 1, BaseGain: 10, OtherGain: -5, Delta: -15},
+	}, d.Symbols)
+
+	assert.Len(t, d.Matched, 2)
+	assert.Equal(t, []UnmatchedTrade{
+		{Symbol: "ETH", Report: "a.json", BuyTime: time.Unix(300, 0), SellTime: time.Unix(360, 0), Gain: 10},
+		{Symbol: "ETH", Report: "b.json", BuyTime: time.Unix(270, 0), SellTime: time.Unix(360, 0), Gain: -5},
+	}, d.Unmatched)
+
+	assert.Equal(t, &Divergence{Symbol: "BTC", Time: time.Unix(180, 0), Reason: DiffDivergenceExit}, d.Divergence)
+}
+
+func TestDiffReports_equal(t *testing.T) {
+	r := JsonReport{
+		TotalGain: "10",
+		Deals: map[string][]JsonDeal{
+			"BTC": {{BuyTime: time.Unix(0, 0), SellTime: time.Unix(60, 0), Gain: "10"}},
+		},
+	}
+
+	d := DiffReports("a", r, "b", r)
+	assert.True(t, d.Equal())
+	assert.Nil(t, d.Divergence)
+	assert.Empty(t, d.Unmatched)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteDiffTable(&buf, d))
+	assert.Contains(t, buf.String(), "no divergence")
+	assert.Contains(t, buf.String(), "total_gain")
+}
+
+func TestReadJsonReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"total_gain": "5", "deals": {"BTC": [{"gain": "5"}]}}`), 0o644))
+
+	r, err := ReadJsonReport(path)
+	require.NoError(t, err)
+	assert.Equal(t, "5", r.TotalGain)
+	assert.Len(t, r.Deals["BTC"], 1)
+
+	_, err = ReadJsonReport(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestWriteReportTable(t *testing.T) {
+	report := JsonReport{
+		TotalGain: "15",
+		Metrics:   &JsonMetrics{Trades: 3, WinRate: 0.5},
+		Symbols:   map[string]*JsonMetrics{"BTC": {Trades: 2, WinRate: 0.5}},
+		Deals: map[string][]JsonDeal{
+			"BTC": {{Gain: "10"}, {Gain: "-5"}},
+			"ETH": {{Gain: "10"}},
+		},
+		OpenPositions: map[string]JsonOpenPosition{"SOL": {Qty: "2", EntryPrice: "10", Price: "11", Gain: "2"}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteReportTable(&buf, report))
+
+	out := buf.String()
+	assert.Regexp(t, `total_gain\s+15`, out)
+	assert.Regexp(t, `win_rate\s+0.5`, out)
+	assert.NotContains(t, out, "sharpe")
+	assert.Regexp(t, `BTC\s+2\s+0.5\s+5`, out)
+	assert.Regexp(t, `ETH\s+1\s+-\s+10`, out)
+	assert.Regexp(t, `SOL\s+2\s+10\s+11\s+2`, out)
+}
@@ -0,0 +1,252 @@
+package agent
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/fsutil"
+	"github.com/shopspring/decimal"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+const accountCurve = "account"
+
+type EquityPoint struct {
+	Time   time.Time       `json:"time"`
+	Equity decimal.Decimal `json:"equity"`
+}
+
+type equityCurve struct {
+	interval time.Duration
+	budgets  map[string]decimal.Decimal
+	points   map[string][]EquityPoint
+	mu       sync.Mutex
+}
+
+func newEquityCurve(interval time.Duration, budgets map[string]decimal.Decimal) *equityCurve {
+	return &equityCurve{
+		interval: interval,
+		budgets:  budgets,
+		points:   map[string][]EquityPoint{},
+	}
+}
+
+func (c *equityCurve) Submit(symbol string, t time.Time, equity decimal.Decimal) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p := EquityPoint{Time: t, Equity: equity}
+	points := c.points[symbol]
+	if n := len(points); n > 0 && c.sameSample(points[n-1].Time, t) {
+		points[n-1] = p
+		return
+	}
+
+	c.points[symbol] = append(points, p)
+}
+
+func (c *equityCurve) sameSample(a, b time.Time) bool {
+	if c.interval <= 0 {
+		return a.Equal(b)
+	}
+
+	return a.Truncate(c.interval).Equal(b.Truncate(c.interval))
+}
+
+func (c *equityCurve) Series(symbol string) []EquityPoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return slices.Clone(c.points[symbol])
+}
+
+func (c *equityCurve) Curves() map[string][]EquityPoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	curves := make(map[string][]EquityPoint, len(c.points)+1)
+	for symbol, points := range c.points {
+		curves[symbol] = slices.Clone(points)
+	}
+	if len(c.points) > 0 {
+		curves[accountCurve] = c.account()
+	}
+
+	return curves
+}
+
+func (c *equityCurve) account() []EquityPoint {
+	var times []time.Time
+	for _, points := range c.points {
+		for _, p := range points {
+			times = append(times, p.Time)
+		}
+	}
+	slices.SortFunc(times, func(a, b time.Time) int {
+		return a.Compare(b)
+	})
+	times = slices.CompactFunc(times, func(a, b time.Time) bool {
+		return a.Equal(b)
+	})
+
+	last := make(map[string]decimal.Decimal, len(c.budgets))
+	for symbol, budget := range c.budgets {
+		last[symbol] = budget
+	}
+
+	next := make(map[string]int, len(c.points))
+	account := make([]EquityPoint, 0, len(times))
+	for _, t := range times {
+		total := decimal.Zero
+		for symbol, points := range c.points {
+			i := next[symbol]
+			for i < len(points) && !points[i].Time.After(t) {
+				last[symbol] = points[i].Equity
+				i++
+			}
+			next[symbol] = i
+		}
+		for _, e := range last {
+			total = total.Add(e)
+		}
+
+		account = append(account, EquityPoint{Time: t, Equity: total})
+	}
+
+	return account
+}
+
+func (c *equityCurve) WriteCsv(w io.Writer) error {
+	curves := c.Curves()
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"curve", "time", "equity"}); err != nil {
+		return fmt.Errorf("failed to write equity header: %w", err)
+	}
+
+	for _, name := range curveNames(curves) {
+		for _, p := range curves[name] {
+			if err := cw.Write([]string{name, p.Time.Format(time.RFC3339), p.Equity.String()}); err != nil {
+				return fmt.Errorf("failed to write equity point: %w", err)
+			}
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to flush equity csv: %w", err)
+	}
+
+	return nil
+}
+
+func (c *equityCurve) WriteJson(w io.Writer) error {
+	e := json.NewEncoder(w)
+	if err := e.Encode(c.Curves()); err != nil {
+		return fmt.Errorf("failed to write equity curve: %w", err)
+	}
+
+	return nil
+}
+
+func (c *equityCurve) WritePlot(w io.Writer) error {
+	p, err := newEquityPlot(c.Curves())
+	if err != nil {
+		return err
+	}
+
+	return writePlot(w, p, "png")
+}
+
+func newEquityPlot(curves map[string][]EquityPoint) (*plot.Plot, error) {
+	p := plot.New()
+	p.Title.Text = "Equity"
+	p.Y.Label.Text = "Equity"
+	p.X.Tick.Marker = plot.TimeTicks{Format: "2006-01-02\n15:04"}
+	p.Legend.Top = true
+	p.Legend.Left = true
+
+	for i, name := range curveNames(curves) {
+		l, err := plotter.NewLine(equityXYs(curves[name]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create equity line for %s: %w", name, err)
+		}
+		l.Color = plotutil.Color(i)
+		if name == accountCurve {
+			l.Color = color.Black
+			l.Width = vg.Points(2)
+		}
+
+		p.Add(l)
+		p.Legend.Add(name, l)
+	}
+
+	return p, nil
+}
+
+func equityXYs(points []EquityPoint) plotter.XYs {
+	pts := make(plotter.XYs, len(points))
+	for i, e := range points {
+		pts[i].X = float64(e.Time.Unix())
+		pts[i].Y, _ = e.Equity.Float64()
+	}
+
+	return pts
+}
+
+func writePlot(w io.Writer, p *plot.Plot, format string) error {
+	wt, err := p.WriterTo(12*vg.Inch, 6*vg.Inch, format)
+	if err != nil {
+		return fmt.Errorf("failed to render %s plot: %w", p.Title.Text, err)
+	}
+	if _, err := wt.WriteTo(w); err != nil {
+		return fmt.Errorf("failed to write %s plot: %w", p.Title.Text, err)
+	}
+
+	return nil
+}
+
+func (c *equityCurve) Save(prefix string) error {
+	writers := []struct {
+		ext   string
+		write func(io.Writer) error
+	}{
+		{ext: ".csv", write: c.WriteCsv},
+		{ext: ".json", write: c.WriteJson},
+		{ext: ".png", write: c.WritePlot},
+	}
+
+	for _, w := range writers {
+		if err := fsutil.WriteFile(prefix+w.ext, w.write); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func curveNames(curves map[string][]EquityPoint) []string {
+	names := make([]string, 0, len(curves))
+	for name := range curves {
+		if name != accountCurve {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	if _, ok := curves[accountCurve]; ok {
+		names = append(names, accountCurve)
+	}
+
+	return names
+}
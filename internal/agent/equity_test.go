@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEquityCurve_Submit(t *testing.T) {
+	tbl := []struct {
+		interval time.Duration
+		expected []EquityPoint
+	}{
+		{
+			expected: []EquityPoint{
+				{Time: time.Unix(0, 0), Equity: decimal.NewFromInt(100)},
+				{Time: time.Unix(60, 0), Equity: decimal.NewFromInt(110)},
+				{Time: time.Unix(120, 0), Equity: decimal.NewFromInt(90)},
+				{Time: time.Unix(3600, 0), Equity: decimal.NewFromInt(120)},
+			},
+		},
+		{
+			interval: time.Hour,
+			expected: []EquityPoint{
+				{Time: time.Unix(120, 0), Equity: decimal.NewFromInt(90)},
+				{Time: time.Unix(3600, 0), Equity: decimal.NewFromInt(120)},
+			},
+		},
+	}
+
+	for _, c := range tbl {
+		t.Run(c.interval.String(), func(t *testing.T) {
+			e := newEquityCurve(c.interval, nil)
+			e.Submit("BTC", time.Unix(0, 0), decimal.NewFromInt(100))
+			e.Submit("BTC", time.Unix(60, 0), decimal.NewFromInt(110))
+			e.Submit("BTC", time.Unix(120, 0), decimal.NewFromInt(90))
+			e.Submit("BTC", time.Unix(3600, 0), decimal.NewFromInt(120))
+
+			assert.Equal(t, c.expected, e.Curves()["BTC"])
+		})
+	}
+}
+
+func TestEquityCurve_account(t *testing.T) {
+	e := newEquityCurve(0, map[string]decimal.Decimal{
+		"BTC": decimal.NewFromInt(100),
+		"ETH": decimal.NewFromInt(50),
+	})
+	e.Submit("BTC", time.Unix(0, 0), decimal.NewFromInt(110))
+	e.Submit("ETH", time.Unix(60, 0), decimal.NewFromInt(40))
+	e.Submit("BTC", time.Unix(120, 0), decimal.NewFromInt(120))
+	e.Submit("ETH", time.Unix(120, 0), decimal.NewFromInt(60))
+
+	assert.Equal(t, []EquityPoint{
+		{Time: time.Unix(0, 0), Equity: decimal.NewFromInt(160)},
+		{Time: time.Unix(60, 0), Equity: decimal.NewFromInt(150)},
+		{Time: time.Unix(120, 0), Equity: decimal.NewFromInt(180)},
+	}, e.Curves()[accountCurve])
+}
+
+func TestEquityCurve_WriteCsv(t *testing.T) {
+	e := newEquityCurve(0, map[string]decimal.Decimal{"BTC": decimal.NewFromInt(100)})
+	e.Submit("BTC", time.Unix(0, 0).UTC(), decimal.NewFromFloat(100.5))
+
+	var buf bytes.Buffer
+	require.NoError(t, e.WriteCsv(&buf))
+	assert.Equal(t, "curve,time,equity\n"+
+		"BTC,1970-01-01T00:00:00Z,100.5\n"+
+		"account,1970-01-01T00:00:00Z,100.5\n", buf.String())
+}
+
+func TestEquityCurve_Save(t *testing.T) {
+	e := newEquityCurve(0, nil)
+	e.Submit("BTC", time.Unix(0, 0), decimal.NewFromInt(100))
+	e.Submit("BTC", time.Unix(60, 0), decimal.NewFromInt(105))
+
+	prefix := filepath.Join(t.TempDir(), "report.equity")
+	require.NoError(t, e.Save(prefix))
+
+	for _, ext := range []string{".csv", ".json", ".png"} {
+		info, err := os.Stat(prefix + ext)
+		require.NoError(t, err)
+		assert.Positive(t, info.Size())
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"time"
 
 	"github.com/gamma-omg/trading-bot/internal/config"
 	"github.com/gamma-omg/trading-bot/internal/indicator"
@@ -12,22 +15,30 @@ import (
 	"github.com/gamma-omg/trading-bot/internal/platform/emulator"
 )
 
-func createIndicator(cfg config.IndicatorReference, asset *market.Asset) (tradingIndicator, error) {
+func createIndicator(cfg config.IndicatorReference, asset *market.Asset, barDuration time.Duration) (tradingIndicator, error) {
 	macd, ok := cfg.Indicator.(config.MACD)
 	if ok {
-		return indicator.NewMACD(macd, asset), nil
+		bars, err := indicatorBars(asset, macd.Timeframe, macd.Transform, barDuration)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create macd indicator: %w", err)
+		}
+		return withTimeframe(indicator.NewMACD(macd, bars), bars, macd.Timeframe), nil
 	}
 
 	rsi, ok := cfg.Indicator.(config.RSI)
 	if ok {
-		return indicator.NewRSI(rsi, asset), nil
+		bars, err := indicatorBars(asset, rsi.Timeframe, rsi.Transform, barDuration)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create rsi indicator: %w", err)
+		}
+		return withTimeframe(indicator.NewRSI(rsi, bars), bars, rsi.Timeframe), nil
 	}
 
 	ensemble, ok := cfg.Indicator.(config.Ensemble)
 	if ok {
 		children := make([]indicator.WeightedIndicator, len(ensemble))
 		for i, c := range ensemble {
-			child, err := createIndicator(c.IndRef, asset)
+			child, err := createIndicator(c.IndRef, asset, barDuration)
 			if err != nil {
 				return nil, fmt.Errorf("failed to create child indicator: %w", err)
 			}
@@ -44,6 +55,25 @@ func createIndicator(cfg config.IndicatorReference, asset *market.Asset) (tradin
 	return nil, fmt.Errorf("unknown indicator: %v", cfg)
 }
 
+func indicatorBars(asset *market.Asset, timeframe time.Duration, transform string, barDuration time.Duration) (*market.Asset, error) {
+	if timeframe > 0 {
+		if timeframe < barDuration {
+			return nil, fmt.Errorf("timeframe %s is shorter than the strategy bars %s", timeframe, barDuration)
+		}
+		asset = asset.Timeframe(timeframe, barDuration)
+	}
+
+	return asset.View(transform)
+}
+
+func withTimeframe(ind tradingIndicator, bars *market.Asset, timeframe time.Duration) tradingIndicator {
+	if timeframe <= 0 {
+		return ind
+	}
+
+	return indicator.NewTimeframeIndicator(ind, bars)
+}
+
 func createPlatform(log *slog.Logger, cfg config.PlatformReference) (tradingPlatform, error) {
 	alpacaCfg, ok := cfg.Platform.(config.Alpaca)
 	if ok {
@@ -57,3 +87,12 @@ func createPlatform(log *slog.Logger, cfg config.PlatformReference) (tradingPlat
 
 	return nil, errors.New("unknown trading platform")
 }
+
+func createClock(cfg config.Config) barsClock {
+	emulatorCfg, ok := cfg.PlatformRef.Platform.(config.Emulator)
+	if !ok || !emulatorCfg.Sync {
+		return noClock{}
+	}
+
+	return emulator.NewClock(slices.Sorted(maps.Keys(cfg.Strategies)))
+}
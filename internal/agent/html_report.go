@@ -0,0 +1,429 @@
+package agent
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"image/color"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/gamma-omg/trading-bot/internal/market"
+	"github.com/shopspring/decimal"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+const (
+	ReportFormatJson = "json"
+	ReportFormatHtml = "html"
+
+	maxChartPoints = 2000
+)
+
+//go:embed report.html.tmpl
+var htmlReportTemplate string
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"pct": formatPct,
+}).Parse(htmlReportTemplate))
+
+type HtmlReportBuilder struct {
+	json   *JsonReportBuilder
+	equity *equityCurve
+	prices *equityCurve
+}
+
+type htmlReportData struct {
+	TotalGain    string
+	TotalGainPct float64
+	Unrealized   string
+	Open         map[string]JsonOpenPosition
+	Symbols      []string
+	Metrics      []htmlMetricRow
+	Equity       template.HTML
+	Drawdown     template.HTML
+	Prices       []htmlPriceChart
+	Trades       []htmlTrade
+}
+
+type htmlMetricRow struct {
+	Name   string
+	Values []string
+}
+
+type htmlPriceChart struct {
+	Symbol string
+	Chart  template.HTML
+}
+
+type htmlTrade struct {
+	Symbol   string
+	BuyTime  time.Time
+	SellTime time.Time
+	Spend    float64
+	Gain     float64
+	GainPct  float64
+}
+
+func NewReportBuilder(log *slog.Logger, cfg config.Config) (reportBuilder, error) {
+	format := cfg.ReportFormat
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(cfg.Report)) {
+		case ".html", ".htm":
+			format = ReportFormatHtml
+		default:
+			format = ReportFormatJson
+		}
+	}
+
+	switch format {
+	case ReportFormatJson:
+		return NewJsonReportBuilder(log, cfg), nil
+	case ReportFormatHtml:
+		return NewHtmlReportBuilder(log, cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown report format: %s", format)
+	}
+}
+
+func NewHtmlReportBuilder(log *slog.Logger, cfg config.Config) *HtmlReportBuilder {
+	var interval time.Duration
+	if cfg.Equity != nil {
+		interval = cfg.Equity.Interval
+	}
+
+	j := NewJsonReportBuilder(log, cfg)
+	return &HtmlReportBuilder{
+		json:   j,
+		equity: newEquityCurve(interval, j.budgets),
+		prices: newEquityCurve(interval, nil),
+	}
+}
+
+func (r *HtmlReportBuilder) SubmitDeal(d market.Deal) {
+	r.json.SubmitDeal(d)
+}
+
+func (r *HtmlReportBuilder) SubmitEquity(symbol string, t time.Time, equity decimal.Decimal) {
+	r.json.SubmitEquity(symbol, t, equity)
+	r.equity.Submit(symbol, t, equity)
+}
+
+func (r *HtmlReportBuilder) SubmitPrice(symbol string, t time.Time, price decimal.Decimal) {
+	r.json.SubmitPrice(symbol, t, price)
+	r.prices.Submit(symbol, t, price)
+}
+
+func (r *HtmlReportBuilder) SubmitOpenPosition(p market.Position, last market.Bar) {
+	r.json.SubmitOpenPosition(p, last)
+}
+
+func (r *HtmlReportBuilder) WriteEquity(prefix string) error {
+	return r.json.WriteEquity(prefix)
+}
+
+func (r *HtmlReportBuilder) Report() JsonReport {
+	return r.json.Report()
+}
+
+func (r *HtmlReportBuilder) Write(w io.Writer) error {
+	return writeHtmlReport(w, r.json.build(), r.json.closedDeals(), r.equity, r.prices)
+}
+
+func WriteHtmlReport(w io.Writer, report JsonReport) error {
+	deals := make(map[string][]market.Deal, len(report.Deals))
+	for symbol, jd := range report.Deals {
+		for _, d := range jd {
+			deals[symbol] = append(deals[symbol], jsonDealToDeal(symbol, d))
+		}
+	}
+
+	return writeHtmlReport(w, report, deals, newEquityCurve(0, nil), newEquityCurve(0, nil))
+}
+
+func writeHtmlReport(w io.Writer, report JsonReport, deals map[string][]market.Deal, equity, prices *equityCurve) error {
+	data := htmlReportData{
+		TotalGain:    report.TotalGain,
+		TotalGainPct: report.TotalGainPct,
+		Unrealized:   report.UnrealizedGain,
+		Open:         report.OpenPositions,
+	}
+	for symbol := range deals {
+		data.Symbols = append(data.Symbols, symbol)
+	}
+	for symbol := range report.Benchmarks {
+		if _, ok := deals[symbol]; !ok {
+			data.Symbols = append(data.Symbols, symbol)
+		}
+	}
+	sort.Strings(data.Symbols)
+	data.Metrics = htmlMetrics(report, data.Symbols)
+
+	curves := equity.Curves()
+	if len(curves) > 0 {
+		var err error
+		if data.Equity, err = equityChart(curves); err != nil {
+			return err
+		}
+		if data.Drawdown, err = drawdownChart(curves[accountCurve]); err != nil {
+			return err
+		}
+	}
+
+	for _, symbol := range curveNames(curves) {
+		if symbol == accountCurve {
+			continue
+		}
+
+		chart, err := priceChart(symbol, prices.Series(symbol), deals[symbol])
+		if err != nil {
+			return err
+		}
+		data.Prices = append(data.Prices, htmlPriceChart{Symbol: symbol, Chart: chart})
+	}
+
+	for _, symbol := range data.Symbols {
+		for _, d := range deals[symbol] {
+			data.Trades = append(data.Trades, newHtmlTrade(d))
+		}
+	}
+	slices.SortStableFunc(data.Trades, func(a, b htmlTrade) int {
+		return a.BuyTime.Compare(b.BuyTime)
+	})
+
+	if err := htmlReport.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to write html report: %w", err)
+	}
+
+	return nil
+}
+
+func equityChart(curves map[string][]EquityPoint) (template.HTML, error) {
+	sampled := make(map[string][]EquityPoint, len(curves))
+	for name, points := range curves {
+		sampled[name] = downsample(points, maxChartPoints)
+	}
+
+	p, err := newEquityPlot(sampled)
+	if err != nil {
+		return "", err
+	}
+
+	return svgPlot(p)
+}
+
+func drawdownChart(account []EquityPoint) (template.HTML, error) {
+	account = downsample(account, maxChartPoints)
+
+	pts := make(plotter.XYs, len(account))
+	peak := 0.0
+	for i, e := range account {
+		v, _ := e.Equity.Float64()
+		peak = max(peak, v)
+
+		pts[i].X = float64(e.Time.Unix())
+		if peak > 0 {
+			pts[i].Y = (v - peak) / peak * 100
+		}
+	}
+
+	p := plot.New()
+	p.Title.Text = "Drawdown"
+	p.Y.Label.Text = "Drawdown, %"
+	p.X.Tick.Marker = plot.TimeTicks{Format: "2006-01-02\n15:04"}
+
+	l, err := plotter.NewLine(pts)
+	if err != nil {
+		return "", fmt.Errorf("failed to create drawdown line: %w", err)
+	}
+	l.Color = color.RGBA{R: 200, A: 255}
+	l.FillColor = color.RGBA{R: 200, A: 64}
+	p.Add(l)
+
+	return svgPlot(p)
+}
+
+func priceChart(symbol string, prices []EquityPoint, deals []market.Deal) (template.HTML, error) {
+	p := plot.New()
+	p.Title.Text = symbol
+	p.Y.Label.Text = "Price"
+	p.X.Tick.Marker = plot.TimeTicks{Format: "2006-01-02\n15:04"}
+	p.Legend.Top = true
+	p.Legend.Left = true
+
+	l, err := plotter.NewLine(equityXYs(downsample(prices, maxChartPoints)))
+	if err != nil {
+		return "", fmt.Errorf("failed to create price line for %s: %w", symbol, err)
+	}
+	p.Add(l)
+
+	if len(deals) > 0 {
+		buys := make(plotter.XYs, len(deals))
+		sells := make(plotter.XYs, len(deals))
+		for i, d := range deals {
+			buys[i].X = float64(d.BuyTime.Unix())
+			buys[i].Y, _ = d.BuyPrice.Float64()
+			sells[i].X = float64(d.SellTime.Unix())
+			sells[i].Y, _ = d.SellPrice.Float64()
+		}
+
+		markers := []struct {
+			name  string
+			pts   plotter.XYs
+			color color.Color
+			shape draw.GlyphDrawer
+		}{
+			{name: "entry", pts: buys, color: color.RGBA{G: 160, A: 255}, shape: draw.TriangleGlyph{}},
+			{name: "exit", pts: sells, color: color.RGBA{R: 200, A: 255}, shape: draw.CircleGlyph{}},
+		}
+		for _, m := range markers {
+			s, err := plotter.NewScatter(m.pts)
+			if err != nil {
+				return "", fmt.Errorf("failed to create %s markers for %s: %w", m.name, symbol, err)
+			}
+			s.GlyphStyle.Color = m.color
+			s.GlyphStyle.Shape = m.shape
+			s.GlyphStyle.Radius = vg.Points(4)
+
+			p.Add(s)
+			p.Legend.Add(m.name, s)
+		}
+	}
+
+	return svgPlot(p)
+}
+
+func svgPlot(p *plot.Plot) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := writePlot(&buf, p, "svg"); err != nil {
+		return "", err
+	}
+
+	svg := buf.String()
+	if i := strings.Index(svg, "<svg"); i > 0 {
+		svg = svg[i:]
+	}
+
+	return template.HTML(svg), nil
+}
+
+func downsample(points []EquityPoint, n int) []EquityPoint {
+	if len(points) <= n {
+		return points
+	}
+
+	step := (len(points) + n - 1) / n
+	sampled := make([]EquityPoint, 0, n+1)
+	for i := 0; i < len(points); i += step {
+		sampled = append(sampled, points[i])
+	}
+	if last := points[len(points)-1]; !sampled[len(sampled)-1].Time.Equal(last.Time) {
+		sampled = append(sampled, last)
+	}
+
+	return sampled
+}
+
+type htmlMetric[T any] struct {
+	name  string
+	value func(m *T) string
+}
+
+func htmlMetrics(report JsonReport, symbols []string) []htmlMetricRow {
+	var rows []htmlMetricRow
+	if report.Metrics != nil {
+		columns := []*JsonMetrics{report.Metrics}
+		for _, symbol := range symbols {
+			columns = append(columns, report.Symbols[symbol])
+		}
+
+		rows = append(rows, htmlMetricRows(columns, []htmlMetric[JsonMetrics]{
+			{"Trades", func(m *JsonMetrics) string { return fmt.Sprint(m.Trades) }},
+			{"Win rate", func(m *JsonMetrics) string { return formatPct(m.WinRate) }},
+			{"Avg win", func(m *JsonMetrics) string { return m.AvgWin }},
+			{"Avg loss", func(m *JsonMetrics) string { return m.AvgLoss }},
+			{"Expectancy", func(m *JsonMetrics) string { return m.Expectancy }},
+			{"Profit factor", func(m *JsonMetrics) string { return formatRatio(m.ProfitFactor) }},
+			{"Max drawdown", func(m *JsonMetrics) string { return formatPct(m.MaxDrawdown) }},
+			{"Max drawdown duration", func(m *JsonMetrics) string { return m.MaxDrawdownDuration }},
+			{"Sharpe", func(m *JsonMetrics) string { return formatRatio(m.Sharpe) }},
+			{"Sortino", func(m *JsonMetrics) string { return formatRatio(m.Sortino) }},
+			{"Exposure", func(m *JsonMetrics) string { return formatPct(m.Exposure) }},
+			{"Avg holding time", func(m *JsonMetrics) string { return m.AvgHoldingTime }},
+		})...)
+	}
+
+	if report.Benchmark != nil {
+		columns := []*JsonBenchmark{report.Benchmark}
+		for _, symbol := range symbols {
+			columns = append(columns, report.Benchmarks[symbol])
+		}
+
+		rows = append(rows, htmlMetricRows(columns, []htmlMetric[JsonBenchmark]{
+			{"Benchmark", func(b *JsonBenchmark) string { return b.Symbol }},
+			{"Benchmark return", func(b *JsonBenchmark) string { return formatPct(b.Return) }},
+			{"Strategy return", func(b *JsonBenchmark) string { return formatPct(b.StrategyReturn) }},
+			{"Excess return", func(b *JsonBenchmark) string { return formatPct(b.ExcessReturn) }},
+			{"Alpha", func(b *JsonBenchmark) string { return formatRatio(b.Alpha) }},
+			{"Beta", func(b *JsonBenchmark) string { return formatRatio(b.Beta) }},
+			{"Correlation", func(b *JsonBenchmark) string { return formatRatio(b.Correlation) }},
+		})...)
+	}
+
+	return rows
+}
+
+func htmlMetricRows[T any](columns []*T, metrics []htmlMetric[T]) []htmlMetricRow {
+	rows := make([]htmlMetricRow, len(metrics))
+	for i, metric := range metrics {
+		rows[i].Name = metric.name
+		for _, m := range columns {
+			v := "-"
+			if m != nil {
+				v = metric.value(m)
+			}
+			rows[i].Values = append(rows[i].Values, v)
+		}
+	}
+
+	return rows
+}
+
+func newHtmlTrade(d market.Deal) htmlTrade {
+	gain := dealGain(d)
+
+	t := htmlTrade{
+		Symbol:   d.Symbol,
+		BuyTime:  d.BuyTime,
+		SellTime: d.SellTime,
+	}
+	t.Spend, _ = d.Spend.Float64()
+	t.Gain, _ = gain.Float64()
+	if !d.Spend.IsZero() {
+		t.GainPct, _ = gain.Div(d.Spend).Float64()
+	}
+
+	return t
+}
+
+func formatPct(v float64) string {
+	return fmt.Sprintf("%.2f%%", v*100)
+}
+
+func formatRatio(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.2f", *v)
+}
@@ -0,0 +1,130 @@
+package agent
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/gamma-omg/trading-bot/internal/market"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReportBuilder(t *testing.T) {
+	tbl := []struct {
+		report   string
+		format   string
+		expected reportBuilder
+		err      bool
+	}{
+		{report: "report.json", expected: &JsonReportBuilder{}},
+		{report: "report.html", expected: &HtmlReportBuilder{}},
+		{report: "report.HTM", expected: &HtmlReportBuilder{}},
+		{report: "report.out", format: "html", expected: &HtmlReportBuilder{}},
+		{report: "report.html", format: "json", expected: &JsonReportBuilder{}},
+		{report: "report.json", format: "xml", err: true},
+	}
+
+	for _, c := range tbl {
+		t.Run(c.report+"_"+c.format, func(t *testing.T) {
+			r, err := NewReportBuilder(slog.New(slog.DiscardHandler), config.Config{
+				Report:       c.report,
+				ReportFormat: c.format,
+			})
+			if c.err {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.IsType(t, c.expected, r)
+		})
+	}
+}
+
+func TestHtmlReportBuilder_Write(t *testing.T) {
+	r := NewHtmlReportBuilder(slog.New(slog.DiscardHandler), config.Config{
+		Strategies: map[string]config.Strategy{"BTC": {Budget: 1000}},
+	})
+
+	for i, price := range []int64{100, 110, 105, 120} {
+		ts := time.Unix(int64(i)*3600, 0)
+		r.SubmitPrice("BTC", ts, decimal.NewFromInt(price))
+		r.SubmitEquity("BTC", ts, decimal.NewFromInt(900+price))
+	}
+	r.SubmitDeal(market.Deal{
+		Symbol:    "BTC",
+		BuyTime:   time.Unix(0, 0),
+		SellTime:  time.Unix(3*3600, 0),
+		BuyPrice:  decimal.NewFromInt(100),
+		SellPrice: decimal.NewFromInt(120),
+		Qty:       decimal.NewFromInt(5),
+		Spend:     decimal.NewFromInt(500),
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, r.Write(&buf))
+
+	html := buf.String()
+	assert.Contains(t, html, "<h2>Metrics</h2>")
+	assert.Contains(t, html, "<h2>Equity</h2>")
+	assert.Contains(t, html, "<h2>Drawdown</h2>")
+	assert.Contains(t, html, "<h2>Trades</h2>")
+	assert.Contains(t, html, "<td data-sort=\"100\">100.00</td>")
+	assert.Contains(t, html, "20.00%")
+	assert.Contains(t, html, "<td>Benchmark return</td>")
+	assert.NotContains(t, html, "<?xml")
+	assert.Equal(t, 3, bytes.Count(buf.Bytes(), []byte("<svg")))
+}
+
+func TestHtmlReportBuilder_WriteEmpty(t *testing.T) {
+	r := NewHtmlReportBuilder(slog.New(slog.DiscardHandler), config.Config{})
+
+	var buf bytes.Buffer
+	require.NoError(t, r.Write(&buf))
+	assert.Contains(t, buf.String(), "No closed trades.")
+	assert.NotContains(t, buf.String(), "<svg")
+}
+
+func TestDownsample(t *testing.T) {
+	var points []EquityPoint
+	for i := range 10 {
+		points = append(points, EquityPoint{Time: time.Unix(int64(i), 0)})
+	}
+
+	assert.Equal(t, points, downsample(points, 10))
+
+	sampled := downsample(points, 4)
+	assert.Equal(t, []EquityPoint{points[0], points[3], points[6], points[9]}, sampled)
+
+	sampled = downsample(points, 3)
+	assert.Equal(t, []EquityPoint{points[0], points[4], points[8], points[9]}, sampled)
+}
+
+func TestWriteHtmlReport(t *testing.T) {
+	report := JsonReport{
+		TotalGain: "20",
+		Metrics:   &JsonMetrics{Trades: 1, WinRate: 1},
+		Deals: map[string][]JsonDeal{"BTC": {{
+			BuyTime:  time.Unix(0, 0),
+			SellTime: time.Unix(3600, 0),
+			Spend:    "500",
+			Gain:     "20",
+			GainPct:  0.04,
+		}}},
+		OpenPositions: map[string]JsonOpenPosition{"ETH": {Qty: "1", Spend: "100", Value: "110", Gain: "10"}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteHtmlReport(&buf, report))
+
+	html := buf.String()
+	assert.Contains(t, html, "<h2>Metrics</h2>")
+	assert.Contains(t, html, "<h2>Trades</h2>")
+	assert.Contains(t, html, "4.00%")
+	assert.Contains(t, html, "ETH")
+	assert.NotContains(t, html, "<h2>Equity</h2>")
+}
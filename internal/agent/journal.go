@@ -0,0 +1,135 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/indicator"
+	"github.com/gamma-omg/trading-bot/internal/market"
+)
+
+const (
+	JournalActionNone = "none"
+	JournalActionBuy  = "buy"
+	JournalActionSell = "sell"
+
+	JournalReasonSignal    = "signal"
+	JournalReasonValidator = "validator"
+	JournalReasonEndOfRun  = "end_of_run"
+)
+
+type JournalRecord struct {
+	Symbol         string                 `json:"symbol"`
+	Time           time.Time              `json:"time"`
+	Bar            JournalBar             `json:"bar"`
+	Signal         *indicator.SignalTrace `json:"signal,omitempty"`
+	BuyConfidence  float64                `json:"buy_confidence"`
+	SellConfidence float64                `json:"sell_confidence"`
+	Action         string                 `json:"action"`
+	Orders         []JournalOrder         `json:"orders,omitempty"`
+}
+
+type JournalBar struct {
+	Open   string `json:"open"`
+	High   string `json:"high"`
+	Low    string `json:"low"`
+	Close  string `json:"close"`
+	Volume string `json:"volume"`
+}
+
+type JournalOrder struct {
+	Side   string       `json:"side"`
+	Reason string       `json:"reason"`
+	Funds  string       `json:"funds,omitempty"`
+	Size   string       `json:"size,omitempty"`
+	Qty    string       `json:"qty,omitempty"`
+	Fill   *JournalFill `json:"fill,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+type JournalFill struct {
+	Time     time.Time `json:"time"`
+	Price    string    `json:"price"`
+	Qty      string    `json:"qty"`
+	Notional string    `json:"notional"`
+}
+
+type jsonlJournal struct {
+	enc *json.Encoder
+	mu  sync.Mutex
+}
+
+func newJsonlJournal(w io.Writer) *jsonlJournal {
+	return &jsonlJournal{enc: json.NewEncoder(w)}
+}
+
+func (j *jsonlJournal) Record(r JournalRecord) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := j.enc.Encode(r); err != nil {
+		return fmt.Errorf("failed to write journal record: %w", err)
+	}
+
+	return nil
+}
+
+func createJournal(path string) (tradingJournal, io.Closer, error) {
+	if path == "" {
+		return nil, nil, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, nil, fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open journal file: %w", err)
+	}
+
+	return newJsonlJournal(f), f, nil
+}
+
+func (o JournalOrder) failed(err error) (JournalOrder, error) {
+	o.Error = err.Error()
+	return o, err
+}
+
+func newJournalRecord(symbol string, bar market.Bar) JournalRecord {
+	return JournalRecord{
+		Symbol: symbol,
+		Time:   bar.Time,
+		Bar: JournalBar{
+			Open:   bar.Open.String(),
+			High:   bar.High.String(),
+			Low:    bar.Low.String(),
+			Close:  bar.Close.String(),
+			Volume: bar.Volume.String(),
+		},
+		Action: JournalActionNone,
+	}
+}
+
+func buyFill(p *market.Position) *JournalFill {
+	return &JournalFill{
+		Time:     p.OpenTime,
+		Price:    p.EntryPrice.String(),
+		Qty:      p.Qty.String(),
+		Notional: p.Price.String(),
+	}
+}
+
+func sellFill(d market.Deal) *JournalFill {
+	return &JournalFill{
+		Time:     d.SellTime,
+		Price:    d.SellPrice.String(),
+		Qty:      d.Qty.String(),
+		Notional: d.SellPrice.Mul(d.Qty).String(),
+	}
+}
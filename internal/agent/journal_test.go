@@ -0,0 +1,174 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/gamma-omg/trading-bot/internal/indicator"
+	"github.com/gamma-omg/trading-bot/internal/market"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockJournal struct {
+	records []JournalRecord
+}
+
+func (m *mockJournal) Record(r JournalRecord) error {
+	m.records = append(m.records, r)
+	return nil
+}
+
+func TestRun_journal(t *testing.T) {
+	asset := market.NewAsset("BTC", 1)
+	asset.Receive(market.Bar{
+		Time:   time.Unix(60, 0),
+		Open:   decimal.NewFromInt(9),
+		High:   decimal.NewFromInt(11),
+		Low:    decimal.NewFromInt(8),
+		Close:  decimal.NewFromInt(10),
+		Volume: decimal.NewFromInt(5),
+	})
+
+	j := &mockJournal{}
+	s := TradingStrategy{
+		asset:        asset,
+		log:          slog.Default(),
+		cfg:          config.Strategy{Budget: 1000, BuyConfidence: 0.5, SellConfidence: 0.7},
+		posMan:       &mockPositionManager{qtyFunc: func(size decimal.Decimal, _ string) decimal.Decimal { return size.Div(decimal.NewFromInt(10)) }},
+		posScaler:    &mockPositionScaler{scaleFunc: func(budget decimal.Decimal, _ float64) decimal.Decimal { return budget }},
+		posValidator: &mockPositionValidator{},
+		acc:          &mockAccount{balance: 500},
+		report:       &mockReport{},
+		journal:      j,
+		indicator:    &mockIndicator{act: indicator.ActBuy, confidence: 0.8},
+	}
+
+	require.NoError(t, s.Run(context.Background()))
+	require.Len(t, j.records, 1)
+
+	r := j.records[0]
+	assert.Equal(t, "BTC", r.Symbol)
+	assert.Equal(t, time.Unix(60, 0), r.Time)
+	assert.Equal(t, JournalBar{Open: "9", High: "11", Low: "8", Close: "10", Volume: "5"}, r.Bar)
+	assert.Equal(t, &indicator.SignalTrace{Indicator: "mock", Act: "ACT_BUY", Confidence: 0.8}, r.Signal)
+	assert.Equal(t, 0.5, r.BuyConfidence)
+	assert.Equal(t, 0.7, r.SellConfidence)
+	assert.Equal(t, JournalActionBuy, r.Action)
+	require.Len(t, r.Orders, 1)
+	assert.Equal(t, JournalOrder{
+		Side:   JournalActionBuy,
+		Reason: JournalReasonSignal,
+		Funds:  "500",
+		Size:   "500",
+		Fill:   &JournalFill{Price: "0", Qty: "50", Notional: "0"},
+	}, r.Orders[0])
+
+	s.indicator = &mockIndicator{act: indicator.ActHold, confidence: 1}
+	require.NoError(t, s.Run(context.Background()))
+	require.Len(t, j.records, 2)
+	assert.Equal(t, JournalActionNone, j.records[1].Action)
+	assert.Empty(t, j.records[1].Orders)
+
+	require.NoError(t, s.Finish(context.Background(), true))
+	require.Len(t, j.records, 3)
+	assert.Equal(t, JournalActionSell, j.records[2].Action)
+	assert.Equal(t, JournalReasonEndOfRun, j.records[2].Orders[0].Reason)
+	assert.Nil(t, j.records[2].Signal)
+}
+
+func TestRun_journalFailedOrder(t *testing.T) {
+	asset := market.NewAsset("BTC", 1)
+	asset.Receive(market.Bar{Time: time.Unix(60, 0), Close: decimal.NewFromInt(10)})
+
+	j := &mockJournal{}
+	s := TradingStrategy{
+		asset:        asset,
+		log:          slog.Default(),
+		cfg:          config.Strategy{Budget: 1000, BuyConfidence: 0.5},
+		posMan:       &mockPositionManager{err: errors.New("insufficient balance")},
+		posScaler:    &mockPositionScaler{scaleFunc: func(budget decimal.Decimal, _ float64) decimal.Decimal { return budget }},
+		posValidator: &mockPositionValidator{},
+		acc:          &mockAccount{balance: 500},
+		report:       &mockReport{},
+		journal:      j,
+		indicator:    &mockIndicator{act: indicator.ActBuy, confidence: 0.8},
+	}
+
+	err := s.Run(context.Background())
+	require.ErrorContains(t, err, "insufficient balance")
+	require.Len(t, j.records, 1)
+
+	r := j.records[0]
+	assert.Equal(t, JournalActionBuy, r.Action)
+	require.Len(t, r.Orders, 1)
+	assert.Equal(t, JournalOrder{
+		Side:   JournalActionBuy,
+		Reason: JournalReasonSignal,
+		Funds:  "500",
+		Size:   "500",
+		Error:  "failed to open position: insufficient balance",
+	}, r.Orders[0])
+	assert.Nil(t, s.position)
+}
+
+func TestNewTradingAgent_journalOpenedByRun(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "journal.jsonl")
+	cfg := config.Config{
+		Report:      filepath.Join(dir, "report.json"),
+		Journal:     path,
+		PlatformRef: config.PlatformReference{Platform: config.Emulator{}},
+	}
+
+	a, err := NewTradingAgent(slog.New(slog.DiscardHandler), cfg, &mockReport{})
+	require.NoError(t, err)
+	assert.NoFileExists(t, path)
+
+	require.NoError(t, a.Run(context.Background()))
+	assert.FileExists(t, path)
+}
+
+func TestCreateJournal_appends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "journal.jsonl")
+
+	for i := range 2 {
+		j, closer, err := createJournal(path)
+		require.NoError(t, err)
+		require.NoError(t, j.Record(JournalRecord{Symbol: "BTC", Time: time.Unix(int64(i), 0).UTC(), Action: JournalActionNone}))
+		require.NoError(t, closer.Close())
+	}
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var records []JournalRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r JournalRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &r))
+		records = append(records, r)
+	}
+	require.NoError(t, scanner.Err())
+
+	require.Len(t, records, 2)
+	assert.Equal(t, time.Unix(0, 0).UTC(), records[0].Time)
+	assert.Equal(t, time.Unix(1, 0).UTC(), records[1].Time)
+}
+
+func TestCreateJournal_disabled(t *testing.T) {
+	j, closer, err := createJournal("")
+	require.NoError(t, err)
+	assert.Nil(t, j)
+	assert.Nil(t, closer)
+}
@@ -0,0 +1,241 @@
+package agent
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/gamma-omg/trading-bot/internal/market"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	defaultReturnPeriod = 24 * time.Hour
+	tradingYear         = 365 * 24 * time.Hour
+)
+
+type JsonMetrics struct {
+	Trades              int      `json:"trades"`
+	WinRate             float64  `json:"win_rate"`
+	AvgWin              string   `json:"avg_win"`
+	AvgLoss             string   `json:"avg_loss"`
+	Expectancy          string   `json:"expectancy"`
+	ProfitFactor        *float64 `json:"profit_factor,omitempty"`
+	MaxDrawdown         float64  `json:"max_drawdown"`
+	MaxDrawdownDuration string   `json:"max_drawdown_duration"`
+	Sharpe              *float64 `json:"sharpe,omitempty"`
+	Sortino             *float64 `json:"sortino,omitempty"`
+	Exposure            float64  `json:"exposure"`
+	AvgHoldingTime      string   `json:"avg_holding_time"`
+}
+
+func dealGain(d market.Deal) decimal.Decimal {
+	return d.SellPrice.Mul(d.Qty).Sub(d.Spend)
+}
+
+func computeMetrics(deals []market.Deal, capital decimal.Decimal, cfg config.Metrics) *JsonMetrics {
+	if len(deals) == 0 {
+		return nil
+	}
+
+	deals = slices.Clone(deals)
+	slices.SortStableFunc(deals, func(a, b market.Deal) int {
+		return a.SellTime.Compare(b.SellTime)
+	})
+
+	if !capital.IsPositive() {
+		for _, d := range deals {
+			capital = decimal.Max(capital, d.Spend)
+		}
+	}
+
+	m := &JsonMetrics{Trades: len(deals)}
+
+	var wins, losses int
+	grossWin, grossLoss, total := decimal.Zero, decimal.Zero, decimal.Zero
+	var holding time.Duration
+	for _, d := range deals {
+		g := dealGain(d)
+		total = total.Add(g)
+		holding += d.SellTime.Sub(d.BuyTime)
+
+		if g.IsPositive() {
+			wins++
+			grossWin = grossWin.Add(g)
+		} else if g.IsNegative() {
+			losses++
+			grossLoss = grossLoss.Add(g.Neg())
+		}
+	}
+
+	n := decimal.NewFromInt(int64(len(deals)))
+	m.WinRate = float64(wins) / float64(len(deals))
+	m.AvgWin = avgDecimal(grossWin, wins).String()
+	m.AvgLoss = avgDecimal(grossLoss.Neg(), losses).String()
+	m.Expectancy = total.Div(n).String()
+	m.AvgHoldingTime = (holding / time.Duration(len(deals))).String()
+
+	if grossLoss.IsPositive() {
+		pf, _ := grossWin.Div(grossLoss).Float64()
+		m.ProfitFactor = &pf
+	}
+
+	m.MaxDrawdown, m.MaxDrawdownDuration = maxDrawdown(deals, capital)
+	m.Exposure = exposure(deals)
+	m.Sharpe, m.Sortino = riskRatios(deals, capital, cfg)
+
+	return m
+}
+
+func returnPeriod(cfg config.Metrics) time.Duration {
+	if cfg.ReturnPeriod <= 0 {
+		return defaultReturnPeriod
+	}
+
+	return cfg.ReturnPeriod
+}
+
+func avgDecimal(sum decimal.Decimal, n int) decimal.Decimal {
+	if n == 0 {
+		return decimal.Zero
+	}
+
+	return sum.Div(decimal.NewFromInt(int64(n)))
+}
+
+func maxDrawdown(deals []market.Deal, capital decimal.Decimal) (float64, string) {
+	if !capital.IsPositive() {
+		return 0, time.Duration(0).String()
+	}
+
+	equity := capital
+	peak := capital
+	var drawdownStart time.Time
+	var maxDD float64
+	var maxDuration time.Duration
+	for _, d := range deals {
+		equity = equity.Add(dealGain(d))
+		if equity.GreaterThanOrEqual(peak) {
+			if !drawdownStart.IsZero() {
+				maxDuration = max(maxDuration, d.SellTime.Sub(drawdownStart))
+				drawdownStart = time.Time{}
+			}
+			peak = equity
+			continue
+		}
+
+		if drawdownStart.IsZero() {
+			drawdownStart = d.SellTime
+		}
+
+		dd, _ := peak.Sub(equity).Div(peak).Float64()
+		maxDD = max(maxDD, dd)
+	}
+
+	if !drawdownStart.IsZero() {
+		maxDuration = max(maxDuration, deals[len(deals)-1].SellTime.Sub(drawdownStart))
+	}
+
+	return maxDD, maxDuration.String()
+}
+
+func exposure(deals []market.Deal) float64 {
+	intervals := slices.Clone(deals)
+	slices.SortFunc(intervals, func(a, b market.Deal) int {
+		return a.BuyTime.Compare(b.BuyTime)
+	})
+
+	start := intervals[0].BuyTime
+	end := start
+	var exposed time.Duration
+	var curStart, curEnd time.Time
+	for i, d := range intervals {
+		if d.SellTime.After(end) {
+			end = d.SellTime
+		}
+
+		if i == 0 || d.BuyTime.After(curEnd) {
+			exposed += curEnd.Sub(curStart)
+			curStart, curEnd = d.BuyTime, d.SellTime
+			continue
+		}
+		if d.SellTime.After(curEnd) {
+			curEnd = d.SellTime
+		}
+	}
+	exposed += curEnd.Sub(curStart)
+
+	if !end.After(start) {
+		return 0
+	}
+
+	return float64(exposed) / float64(end.Sub(start))
+}
+
+func riskRatios(deals []market.Deal, capital decimal.Decimal, cfg config.Metrics) (sharpe, sortino *float64) {
+	period := returnPeriod(cfg)
+	if !capital.IsPositive() {
+		return
+	}
+
+	start := deals[0].BuyTime
+	for _, d := range deals {
+		if d.BuyTime.Before(start) {
+			start = d.BuyTime
+		}
+	}
+	start = start.Truncate(period)
+	end := deals[len(deals)-1].SellTime
+
+	buckets := int(end.Sub(start)/period) + 1
+	pnl := make([]decimal.Decimal, buckets)
+	for _, d := range deals {
+		i := int(d.SellTime.Sub(start) / period)
+		pnl[i] = pnl[i].Add(dealGain(d))
+	}
+
+	if buckets < 2 {
+		return
+	}
+
+	rf := cfg.RiskFreeRate * float64(period) / float64(tradingYear)
+	returns := make([]float64, buckets)
+	equity := capital
+	for i, p := range pnl {
+		r, _ := p.Div(equity).Float64()
+		returns[i] = r - rf
+		equity = equity.Add(p)
+		if !equity.IsPositive() {
+			return
+		}
+	}
+
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	var variance, downside float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+		if r < 0 {
+			downside += r * r
+		}
+	}
+	variance /= float64(len(returns) - 1)
+	downside /= float64(len(returns))
+
+	scale := math.Sqrt(float64(tradingYear) / float64(period))
+	if variance > 0 {
+		s := mean / math.Sqrt(variance) * scale
+		sharpe = &s
+	}
+	if downside > 0 {
+		s := mean / math.Sqrt(downside) * scale
+		sortino = &s
+	}
+
+	return
+}
@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/gamma-omg/trading-bot/internal/market"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testDeal(symbol string, buyHour, sellHour int, spend, value int64) market.Deal {
+	return market.Deal{
+		Symbol:    symbol,
+		BuyTime:   time.Unix(int64(buyHour)*3600, 0),
+		SellTime:  time.Unix(int64(sellHour)*3600, 0),
+		BuyPrice:  decimal.NewFromInt(spend),
+		SellPrice: decimal.NewFromInt(value),
+		Qty:       decimal.NewFromInt(1),
+		Spend:     decimal.NewFromInt(spend),
+	}
+}
+
+func TestComputeMetrics(t *testing.T) {
+	deals := []market.Deal{
+		testDeal("BTC", 0, 2, 100, 120),
+		testDeal("BTC", 4, 6, 100, 90),
+		testDeal("BTC", 6, 8, 100, 80),
+		testDeal("BTC", 8, 10, 100, 150),
+	}
+
+	m := computeMetrics(deals, decimal.NewFromInt(1000), config.Metrics{ReturnPeriod: 2 * time.Hour})
+	require.NotNil(t, m)
+
+	assert.Equal(t, 4, m.Trades)
+	assert.Equal(t, 0.5, m.WinRate)
+	assert.Equal(t, "35", m.AvgWin)
+	assert.Equal(t, "-15", m.AvgLoss)
+	assert.Equal(t, "10", m.Expectancy)
+	require.NotNil(t, m.ProfitFactor)
+	assert.InDelta(t, 70.0/30.0, *m.ProfitFactor, 1e-9)
+	assert.InDelta(t, 30.0/1020.0, m.MaxDrawdown, 1e-9)
+	assert.Equal(t, "4h0m0s", m.MaxDrawdownDuration)
+	assert.InDelta(t, 0.8, m.Exposure, 1e-9)
+	assert.Equal(t, "2h0m0s", m.AvgHoldingTime)
+	require.NotNil(t, m.Sharpe)
+	require.NotNil(t, m.Sortino)
+	assert.Greater(t, *m.Sortino, *m.Sharpe)
+
+	assert.Nil(t, computeMetrics(nil, decimal.Zero, config.Metrics{}))
+}
+
+func TestComputeMetrics_noLosses(t *testing.T) {
+	m := computeMetrics([]market.Deal{testDeal("BTC", 0, 1, 100, 110)}, decimal.Zero, config.Metrics{})
+	require.NotNil(t, m)
+
+	assert.Nil(t, m.ProfitFactor)
+	assert.Nil(t, m.Sharpe)
+	assert.Equal(t, 1.0, m.Exposure)
+	assert.Equal(t, 0.0, m.MaxDrawdown)
+}
+
+func TestComputeMetrics_allWins(t *testing.T) {
+	deals := []market.Deal{
+		testDeal("BTC", 0, 2, 100, 110),
+		testDeal("BTC", 3, 5, 100, 120),
+		testDeal("BTC", 6, 9, 100, 105),
+	}
+
+	m := computeMetrics(deals, decimal.NewFromInt(1000), config.Metrics{})
+	require.NotNil(t, m)
+
+	assert.Equal(t, 0.0, m.MaxDrawdown)
+	assert.Equal(t, "0s", m.MaxDrawdownDuration)
+}
+
+func TestWrite_negativeGainAndMetrics(t *testing.T) {
+	r := NewJsonReportBuilder(slog.New(slog.DiscardHandler), config.Config{
+		Strategies: map[string]config.Strategy{
+			"BTC": {Budget: 100},
+			"ETH": {Budget: 100},
+		},
+	})
+	r.SubmitDeal(testDeal("BTC", 0, 1, 100, 80))
+	r.SubmitDeal(testDeal("ETH", 0, 2, 100, 90))
+
+	var buff bytes.Buffer
+	require.NoError(t, r.Write(&buff))
+
+	var report JsonReport
+	require.NoError(t, json.Unmarshal(buff.Bytes(), &report))
+
+	assert.Equal(t, "-30", report.TotalGain)
+	assert.Equal(t, -0.15, report.TotalGainPct)
+	require.NotNil(t, report.Metrics)
+	assert.Equal(t, 2, report.Metrics.Trades)
+	assert.InDelta(t, 0.15, report.Metrics.MaxDrawdown, 1e-9)
+	require.Contains(t, report.Symbols, "BTC")
+	assert.InDelta(t, 0.2, report.Symbols["BTC"].MaxDrawdown, 1e-9)
+	assert.InDelta(t, 0.1, report.Symbols["ETH"].MaxDrawdown, 1e-9)
+}
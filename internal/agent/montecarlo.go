@@ -0,0 +1,253 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	MonteCarloBootstrap = "bootstrap"
+	MonteCarloShuffle   = "shuffle"
+	MonteCarloSkip      = "skip"
+
+	defaultMonteCarloRuns = 1000
+	defaultRuinThreshold  = 0.5
+)
+
+type MonteCarloOptions struct {
+	Method        string
+	Runs          int
+	Seed          uint64
+	Capital       float64
+	SkipProb      float64
+	RuinThreshold float64
+}
+
+type MonteCarloReport struct {
+	Method            string       `json:"method"`
+	Runs              int          `json:"runs"`
+	Seed              uint64       `json:"seed"`
+	Trades            int          `json:"trades"`
+	Capital           float64      `json:"capital"`
+	SkipProb          float64      `json:"skip_prob,omitempty"`
+	RuinThreshold     float64      `json:"ruin_threshold"`
+	FinalReturn       Distribution `json:"final_return"`
+	MaxDrawdown       Distribution `json:"max_drawdown"`
+	ProbabilityOfLoss float64      `json:"probability_of_loss"`
+	ProbabilityOfRuin float64      `json:"probability_of_ruin"`
+}
+
+type Distribution struct {
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"std_dev"`
+	Min    float64 `json:"min"`
+	P5     float64 `json:"p5"`
+	P25    float64 `json:"p25"`
+	P50    float64 `json:"p50"`
+	P75    float64 `json:"p75"`
+	P95    float64 `json:"p95"`
+	Max    float64 `json:"max"`
+}
+
+type monteCarloTrade struct {
+	sellTime time.Time
+	gain     float64
+}
+
+func MonteCarlo(r JsonReport, opts MonteCarloOptions) (MonteCarloReport, error) {
+	switch opts.Method {
+	case "":
+		opts.Method = MonteCarloBootstrap
+	case MonteCarloBootstrap, MonteCarloShuffle:
+	case MonteCarloSkip:
+		if opts.SkipProb <= 0 || opts.SkipProb >= 1 {
+			return MonteCarloReport{}, errors.New("skip probability must be between 0 and 1")
+		}
+	default:
+		return MonteCarloReport{}, fmt.Errorf("unknown monte carlo method: %s", opts.Method)
+	}
+	if opts.Runs <= 0 {
+		opts.Runs = defaultMonteCarloRuns
+	}
+	if opts.RuinThreshold <= 0 || opts.RuinThreshold > 1 {
+		opts.RuinThreshold = defaultRuinThreshold
+	}
+
+	trades := monteCarloTrades(r)
+	if len(trades) == 0 {
+		return MonteCarloReport{}, errors.New("report has no closed deals")
+	}
+
+	if opts.Capital <= 0 {
+		return MonteCarloReport{}, errors.New("capital must be positive")
+	}
+
+	gains := make([]float64, len(trades))
+	for i, t := range trades {
+		gains[i] = t.gain
+	}
+
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed))
+	path := make([]float64, 0, len(gains))
+	returns := make([]float64, opts.Runs)
+	drawdowns := make([]float64, opts.Runs)
+	var losses, ruins int
+	for i := range opts.Runs {
+		path = samplePath(path[:0], gains, opts, rng)
+
+		ret, dd, ruined := simulatePath(path, opts.Capital, opts.RuinThreshold)
+		returns[i] = ret
+		drawdowns[i] = dd
+		if ret < 0 {
+			losses++
+		}
+		if ruined {
+			ruins++
+		}
+	}
+
+	return MonteCarloReport{
+		Method:            opts.Method,
+		Runs:              opts.Runs,
+		Seed:              opts.Seed,
+		Trades:            len(gains),
+		Capital:           opts.Capital,
+		SkipProb:          opts.SkipProb,
+		RuinThreshold:     opts.RuinThreshold,
+		FinalReturn:       newDistribution(returns),
+		MaxDrawdown:       newDistribution(drawdowns),
+		ProbabilityOfLoss: float64(losses) / float64(opts.Runs),
+		ProbabilityOfRuin: float64(ruins) / float64(opts.Runs),
+	}, nil
+}
+
+func monteCarloTrades(r JsonReport) []monteCarloTrade {
+	var trades []monteCarloTrade
+	for _, symbol := range slices.Sorted(maps.Keys(r.Deals)) {
+		for _, d := range r.Deals[symbol] {
+			trades = append(trades, monteCarloTrade{sellTime: d.SellTime, gain: parseFloat(d.Gain)})
+		}
+	}
+
+	slices.SortStableFunc(trades, func(a, b monteCarloTrade) int {
+		return a.sellTime.Compare(b.sellTime)
+	})
+
+	return trades
+}
+
+func samplePath(path []float64, gains []float64, opts MonteCarloOptions, rng *rand.Rand) []float64 {
+	switch opts.Method {
+	case MonteCarloShuffle:
+		path = append(path, gains...)
+		rng.Shuffle(len(path), func(i, j int) {
+			path[i], path[j] = path[j], path[i]
+		})
+	case MonteCarloSkip:
+		for _, g := range gains {
+			if rng.Float64() >= opts.SkipProb {
+				path = append(path, g)
+			}
+		}
+	default:
+		for range gains {
+			path = append(path, gains[rng.IntN(len(gains))])
+		}
+	}
+
+	return path
+}
+
+func simulatePath(gains []float64, capital, ruinThreshold float64) (float64, float64, bool) {
+	equity, peak := capital, capital
+	ruinLevel := capital * (1 - ruinThreshold)
+	var maxDD float64
+	ruined := false
+	for _, g := range gains {
+		equity += g
+		if equity > peak {
+			peak = equity
+		}
+		if peak > 0 {
+			maxDD = max(maxDD, (peak-equity)/peak)
+		}
+		if equity <= ruinLevel {
+			ruined = true
+		}
+	}
+
+	return (equity - capital) / capital, maxDD, ruined
+}
+
+func newDistribution(values []float64) Distribution {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+	mean := sum / float64(len(sorted))
+
+	var sq float64
+	for _, v := range sorted {
+		sq += (v - mean) * (v - mean)
+	}
+
+	return Distribution{
+		Mean:   mean,
+		StdDev: math.Sqrt(sq / float64(len(sorted))),
+		Min:    sorted[0],
+		P5:     percentile(sorted, 0.05),
+		P25:    percentile(sorted, 0.25),
+		P50:    percentile(sorted, 0.5),
+		P75:    percentile(sorted, 0.75),
+		P95:    percentile(sorted, 0.95),
+		Max:    sorted[len(sorted)-1],
+	}
+}
+
+func percentile(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	if lo == hi {
+		return sorted[lo]
+	}
+
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
+
+func WriteMonteCarloTable(w io.Writer, r MonteCarloReport) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "method: %s\truns: %d\tseed: %d\ttrades: %d\tcapital: %.6g\t\n\n", r.Method, r.Runs, r.Seed, r.Trades, r.Capital)
+
+	fmt.Fprintln(tw, "metric\tmean\tstd dev\tmin\tp5\tp25\tp50\tp75\tp95\tmax\t")
+	for _, row := range []struct {
+		name string
+		d    Distribution
+	}{
+		{"final_return", r.FinalReturn},
+		{"max_drawdown", r.MaxDrawdown},
+	} {
+		d := row.d
+		fmt.Fprintf(tw, "%s\t%.4g\t%.4g\t%.4g\t%.4g\t%.4g\t%.4g\t%.4g\t%.4g\t%.4g\t\n", row.name, d.Mean, d.StdDev, d.Min, d.P5, d.P25, d.P50, d.P75, d.P95, d.Max)
+	}
+
+	fmt.Fprintf(tw, "\nprobability of loss: %.4g\t\n", r.ProbabilityOfLoss)
+	fmt.Fprintf(tw, "probability of ruin (%.4g%% of capital lost): %.4g\t\n", r.RuinThreshold*100, r.ProbabilityOfRuin)
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write monte carlo report: %w", err)
+	}
+
+	return nil
+}
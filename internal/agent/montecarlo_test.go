@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func monteCarloReport() JsonReport {
+	deal := func(sell int64, gain string) JsonDeal {
+		return JsonDeal{BuyTime: time.Unix(sell-60, 0), SellTime: time.Unix(sell, 0), Spend: "100", Gain: gain}
+	}
+
+	return JsonReport{Deals: map[string][]JsonDeal{
+		"BTC": {deal(60, "50"), deal(180, "-300"), deal(300, "20")},
+		"ETH": {deal(120, "-100"), deal(240, "80")},
+	}}
+}
+
+func TestMonteCarlo_Shuffle(t *testing.T) {
+	r, err := MonteCarlo(monteCarloReport(), MonteCarloOptions{Method: MonteCarloShuffle, Runs: 200, Seed: 3, Capital: 1000})
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, r.Trades)
+	assert.Equal(t, 200, r.Runs)
+	assert.InDelta(t, -0.25, r.FinalReturn.Min, 1e-9)
+	assert.InDelta(t, -0.25, r.FinalReturn.Max, 1e-9)
+	assert.InDelta(t, 0, r.FinalReturn.StdDev, 1e-9)
+	assert.Equal(t, 1.0, r.ProbabilityOfLoss)
+
+	assert.LessOrEqual(t, r.MaxDrawdown.Min, r.MaxDrawdown.P50)
+	assert.GreaterOrEqual(t, r.MaxDrawdown.Max, 0.4/1.05)
+	assert.LessOrEqual(t, r.MaxDrawdown.Max, 0.4/1.0+1e-9)
+}
+
+func TestMonteCarlo_Reproducible(t *testing.T) {
+	for _, method := range []string{MonteCarloBootstrap, MonteCarloShuffle, MonteCarloSkip} {
+		t.Run(method, func(t *testing.T) {
+			opts := MonteCarloOptions{Method: method, Runs: 100, Seed: 42, Capital: 100, SkipProb: 0.3}
+
+			first, err := MonteCarlo(monteCarloReport(), opts)
+			require.NoError(t, err)
+			second, err := MonteCarlo(monteCarloReport(), opts)
+			require.NoError(t, err)
+			assert.Equal(t, first, second)
+
+			opts.Seed = 43
+			third, err := MonteCarlo(monteCarloReport(), opts)
+			require.NoError(t, err)
+			assert.NotEqual(t, first.MaxDrawdown, third.MaxDrawdown)
+		})
+	}
+}
+
+func TestMonteCarlo_Ruin(t *testing.T) {
+	r, err := MonteCarlo(monteCarloReport(), MonteCarloOptions{Method: MonteCarloBootstrap, Runs: 500, Seed: 1, Capital: 400, RuinThreshold: 0.5})
+	require.NoError(t, err)
+
+	assert.Greater(t, r.ProbabilityOfRuin, 0.0)
+	assert.Less(t, r.ProbabilityOfRuin, 1.0)
+}
+
+func TestMonteCarlo_Defaults(t *testing.T) {
+	r, err := MonteCarlo(monteCarloReport(), MonteCarloOptions{Runs: 10, Capital: 1000})
+	require.NoError(t, err)
+
+	assert.Equal(t, MonteCarloBootstrap, r.Method)
+	assert.Equal(t, 1000.0, r.Capital)
+	assert.Equal(t, defaultRuinThreshold, r.RuinThreshold)
+
+	_, err = MonteCarlo(monteCarloReport(), MonteCarloOptions{Runs: 10})
+	assert.EqualError(t, err, "capital must be positive")
+}
+
+func TestMonteCarlo_Errors(t *testing.T) {
+	_, err := MonteCarlo(JsonReport{}, MonteCarloOptions{})
+	assert.Error(t, err)
+
+	_, err = MonteCarlo(monteCarloReport(), MonteCarloOptions{Method: "jackknife"})
+	assert.Error(t, err)
+
+	_, err = MonteCarlo(monteCarloReport(), MonteCarloOptions{Method: MonteCarloSkip})
+	assert.Error(t, err)
+}
+
+func TestSimulatePath(t *testing.T) {
+	ret, dd, ruined := simulatePath([]float64{100, -330, 30}, 1000, 0.2)
+	assert.InDelta(t, -0.2, ret, 1e-9)
+	assert.InDelta(t, 0.3, dd, 1e-9)
+	assert.True(t, ruined)
+
+	ret, dd, ruined = simulatePath([]float64{100, 50}, 1000, 0.2)
+	assert.InDelta(t, 0.15, ret, 1e-9)
+	assert.Zero(t, dd)
+	assert.False(t, ruined)
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5}
+	assert.Equal(t, 1.0, percentile(sorted, 0))
+	assert.Equal(t, 3.0, percentile(sorted, 0.5))
+	assert.Equal(t, 5.0, percentile(sorted, 1))
+	assert.InDelta(t, 1.2, percentile(sorted, 0.05), 1e-9)
+}
+
+func TestWriteMonteCarloTable(t *testing.T) {
+	r, err := MonteCarlo(monteCarloReport(), MonteCarloOptions{Runs: 10, Capital: 1000})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteMonteCarloTable(&buf, r))
+	assert.Contains(t, buf.String(), "final_return")
+	assert.Contains(t, buf.String(), "max_drawdown")
+	assert.Contains(t, buf.String(), "probability of ruin")
+}
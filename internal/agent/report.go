@@ -5,25 +5,48 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/gamma-omg/trading-bot/internal/config"
 	"github.com/gamma-omg/trading-bot/internal/market"
 	"github.com/shopspring/decimal"
 )
 
 type JsonReportBuilder struct {
-	log    *slog.Logger
-	report JsonReport
-	spent  decimal.Decimal
-	gained decimal.Decimal
-	mu     sync.Mutex
+	log        *slog.Logger
+	cfg        config.Metrics
+	budgets    map[string]decimal.Decimal
+	report     JsonReport
+	deals      map[string][]market.Deal
+	equity     *equityCurve
+	benchmark  reportBenchmark
+	spent      decimal.Decimal
+	gained     decimal.Decimal
+	unrealized decimal.Decimal
+	mu         sync.Mutex
+}
+
+type reportBenchmark struct {
+	symbol         string
+	buyCommission  float64
+	sellCommission float64
+	first          map[string]EquityPoint
+	prices         *equityCurve
+	equity         *equityCurve
 }
 
 type JsonReport struct {
-	TotalGain    string                `json:"total_gain,omitempty"`
-	TotalGainPct float64               `json:"total_gain_pct,omitempty"`
-	Deals        map[string][]JsonDeal `json:"deals,omitempty"`
+	TotalGain      string                      `json:"total_gain,omitempty"`
+	TotalGainPct   float64                     `json:"total_gain_pct,omitempty"`
+	UnrealizedGain string                      `json:"unrealized_gain,omitempty"`
+	Metrics        *JsonMetrics                `json:"metrics,omitempty"`
+	Symbols        map[string]*JsonMetrics     `json:"symbols,omitempty"`
+	Benchmark      *JsonBenchmark              `json:"benchmark,omitempty"`
+	Benchmarks     map[string]*JsonBenchmark   `json:"benchmarks,omitempty"`
+	Deals          map[string][]JsonDeal       `json:"deals,omitempty"`
+	OpenPositions  map[string]JsonOpenPosition `json:"open_positions,omitempty"`
 }
 
 type JsonDeal struct {
@@ -34,9 +57,47 @@ type JsonDeal struct {
 	GainPct  float64   `json:"gain_pct,omitempty"`
 }
 
-func NewJsonReportBuilder(log *slog.Logger) *JsonReportBuilder {
+type JsonOpenPosition struct {
+	OpenTime   time.Time `json:"open_time,omitzero,omitempty"`
+	Time       time.Time `json:"time,omitzero,omitempty"`
+	EntryPrice string    `json:"entry_price,omitempty"`
+	Price      string    `json:"price,omitempty"`
+	Qty        string    `json:"qty,omitempty"`
+	Spend      string    `json:"spend,omitempty"`
+	Value      string    `json:"value,omitempty"`
+	Gain       string    `json:"gain,omitempty"`
+	GainPct    float64   `json:"gain_pct,omitempty"`
+}
+
+func NewJsonReportBuilder(log *slog.Logger, cfg config.Config) *JsonReportBuilder {
+	budgets := make(map[string]decimal.Decimal, len(cfg.Strategies))
+	for symbol, s := range cfg.Strategies {
+		budgets[symbol] = decimal.NewFromInt(s.Budget)
+	}
+
+	var equity *equityCurve
+	if cfg.Equity != nil {
+		equity = newEquityCurve(cfg.Equity.Interval, budgets)
+	}
+
+	benchmark := reportBenchmark{
+		symbol: cfg.Benchmark.Symbol,
+		first:  map[string]EquityPoint{},
+		prices: newEquityCurve(returnPeriod(cfg.Metrics), nil),
+		equity: newEquityCurve(returnPeriod(cfg.Metrics), nil),
+	}
+	if emu, ok := cfg.PlatformRef.Platform.(config.Emulator); ok {
+		benchmark.buyCommission = emu.BuyCommission
+		benchmark.sellCommission = emu.SellCommission
+	}
+
 	return &JsonReportBuilder{
-		log: log,
+		log:       log,
+		equity:    equity,
+		benchmark: benchmark,
+		cfg:       cfg.Metrics,
+		budgets:   budgets,
+		deals:     map[string][]market.Deal{},
 		report: JsonReport{
 			Deals: map[string][]JsonDeal{},
 		},
@@ -70,6 +131,7 @@ func (r *JsonReportBuilder) SubmitDeal(d market.Deal) {
 		GainPct:  dealPct,
 	})
 	r.report.Deals[d.Symbol] = deals
+	r.deals[d.Symbol] = append(r.deals[d.Symbol], d)
 
 	r.log.Info("deal closed",
 		slog.String("symbol", d.Symbol),
@@ -79,21 +141,159 @@ func (r *JsonReportBuilder) SubmitDeal(d market.Deal) {
 		slog.Time("sell_time", d.SellTime))
 }
 
+func (r *JsonReportBuilder) SubmitEquity(symbol string, t time.Time, equity decimal.Decimal) {
+	r.benchmark.equity.Submit(symbol, t, equity)
+	if r.equity == nil {
+		return
+	}
+
+	r.equity.Submit(symbol, t, equity)
+}
+
+func (r *JsonReportBuilder) WriteEquity(prefix string) error {
+	if r.equity == nil {
+		return nil
+	}
+
+	if err := r.equity.Save(prefix); err != nil {
+		return fmt.Errorf("failed to save equity curve: %w", err)
+	}
+
+	return nil
+}
+
+func (r *JsonReportBuilder) SubmitPrice(symbol string, t time.Time, price decimal.Decimal) {
+	r.mu.Lock()
+	if _, ok := r.benchmark.first[symbol]; !ok {
+		r.benchmark.first[symbol] = EquityPoint{Time: t, Equity: price}
+	}
+	r.mu.Unlock()
+
+	r.benchmark.prices.Submit(symbol, t, price)
+}
+
+func (r *JsonReportBuilder) SubmitOpenPosition(p market.Position, last market.Bar) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	value := p.Qty.Mul(last.Close)
+	gain := value.Sub(p.Price)
+	gainPct := 0.0
+	if !p.Price.IsZero() {
+		gainPct, _ = gain.Div(p.Price).Float64()
+	}
+
+	if r.report.OpenPositions == nil {
+		r.report.OpenPositions = map[string]JsonOpenPosition{}
+	}
+	r.report.OpenPositions[p.Asset.Symbol] = JsonOpenPosition{
+		OpenTime:   p.OpenTime,
+		Time:       last.Time,
+		EntryPrice: p.EntryPrice.String(),
+		Price:      last.Close.String(),
+		Qty:        p.Qty.String(),
+		Spend:      p.Price.String(),
+		Value:      value.String(),
+		Gain:       gain.String(),
+		GainPct:    gainPct,
+	}
+	r.unrealized = r.unrealized.Add(gain)
+
+	r.log.Info("position left open",
+		slog.String("symbol", p.Asset.Symbol),
+		slog.Float64("gain_pct", gainPct),
+		slog.Time("open_time", p.OpenTime),
+		slog.Time("time", last.Time))
+}
+
 func (r *JsonReportBuilder) Write(w io.Writer) error {
+	e := json.NewEncoder(w)
+	if err := e.Encode(r.build()); err != nil {
+		return fmt.Errorf("failed to write trading report: %w", err)
+	}
+
+	return nil
+}
+
+func (r *JsonReportBuilder) Report() JsonReport {
+	return r.build()
+}
+
+func (r *JsonReportBuilder) build() JsonReport {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if r.gained.IsPositive() {
+	if len(r.deals) > 0 {
 		r.report.TotalGain = r.gained.String()
 	}
 	if !r.spent.IsZero() {
 		r.report.TotalGainPct, _ = r.gained.Div(r.spent).Float64()
 	}
+	if len(r.report.OpenPositions) > 0 {
+		r.report.UnrealizedGain = r.unrealized.String()
+	}
 
-	e := json.NewEncoder(w)
-	if err := e.Encode(r.report); err != nil {
-		return fmt.Errorf("failed to write trading report: %w", err)
+	r.buildMetrics()
+	r.buildBenchmarks()
+	return r.report
+}
+
+func (r *JsonReportBuilder) closedDeals() map[string][]market.Deal {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deals := make(map[string][]market.Deal, len(r.deals))
+	for symbol, d := range r.deals {
+		deals[symbol] = slices.Clone(d)
 	}
 
-	return nil
+	return deals
+}
+
+func (r *JsonReportBuilder) buildMetrics() {
+	var all []market.Deal
+	capital := decimal.Zero
+	symbols := make(map[string]*JsonMetrics, len(r.deals))
+	for symbol, deals := range r.deals {
+		all = append(all, deals...)
+		capital = capital.Add(r.budgets[symbol])
+		symbols[symbol] = computeMetrics(deals, r.budgets[symbol], r.cfg)
+	}
+
+	if len(all) == 0 {
+		return
+	}
+
+	r.report.Metrics = computeMetrics(all, capital, r.cfg)
+	r.report.Symbols = symbols
+}
+
+// MergeReports stitches the closed deals of the reports and recomputes the totals and metrics from them. Equity
+// curves, benchmarks and open positions of the reports are not merged.
+func MergeReports(log *slog.Logger, cfg config.Config, reports ...JsonReport) JsonReport {
+	r := NewJsonReportBuilder(log, cfg)
+	for _, report := range reports {
+		for symbol, deals := range report.Deals {
+			for _, d := range deals {
+				r.SubmitDeal(jsonDealToDeal(symbol, d))
+			}
+		}
+	}
+
+	return r.build()
+}
+
+func jsonDealToDeal(symbol string, d JsonDeal) market.Deal {
+	spend, _ := decimal.NewFromString(d.Spend)
+	gain, _ := decimal.NewFromString(d.Gain)
+
+	return market.Deal{
+		Symbol:    symbol,
+		BuyTime:   d.BuyTime,
+		SellTime:  d.SellTime,
+		BuyPrice:  spend,
+		SellPrice: spend.Add(gain),
+		Qty:       decimal.NewFromInt(1),
+		Spend:     spend,
+	}
 }
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"log/slog"
 	"testing"
+	"time"
 
+	"github.com/gamma-omg/trading-bot/internal/config"
 	"github.com/gamma-omg/trading-bot/internal/market"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
@@ -12,7 +14,7 @@ import (
 )
 
 func TestWrite(t *testing.T) {
-	r := NewJsonReportBuilder(slog.New(slog.DiscardHandler))
+	r := NewJsonReportBuilder(slog.New(slog.DiscardHandler), config.Config{})
 	r.SubmitDeal(market.Deal{
 		Symbol:    "BTC",
 		Qty:       decimal.NewFromInt(10),
@@ -34,6 +36,41 @@ func TestWrite(t *testing.T) {
 {
 	"total_gain": "220",
 	"total_gain_pct": 0.2,
+	"metrics": {
+		"trades": 2,
+		"win_rate": 1,
+		"avg_win": "110",
+		"avg_loss": "0",
+		"expectancy": "110",
+		"max_drawdown": 0,
+		"max_drawdown_duration": "0s",
+		"exposure": 0,
+		"avg_holding_time": "0s"
+	},
+	"symbols": {
+		"BTC": {
+			"trades": 1,
+			"win_rate": 1,
+			"avg_win": "20",
+			"avg_loss": "0",
+			"expectancy": "20",
+			"max_drawdown": 0,
+			"max_drawdown_duration": "0s",
+			"exposure": 0,
+			"avg_holding_time": "0s"
+		},
+		"ETH": {
+			"trades": 1,
+			"win_rate": 1,
+			"avg_win": "200",
+			"avg_loss": "0",
+			"expectancy": "200",
+			"max_drawdown": 0,
+			"max_drawdown_duration": "0s",
+			"exposure": 0,
+			"avg_holding_time": "0s"
+		}
+	},
 	"deals": {
 		"BTC": [{
 			"spend": "100",
@@ -50,7 +87,7 @@ func TestWrite(t *testing.T) {
 }
 
 func TestWrite_emptyReport(t *testing.T) {
-	r := NewJsonReportBuilder(slog.New(slog.DiscardHandler))
+	r := NewJsonReportBuilder(slog.New(slog.DiscardHandler), config.Config{})
 
 	var buff bytes.Buffer
 	err := r.Write(&buff)
@@ -60,7 +97,7 @@ func TestWrite_emptyReport(t *testing.T) {
 }
 
 func TestSubmitDeal_divideByZero(t *testing.T) {
-	r := NewJsonReportBuilder(slog.New(slog.DiscardHandler))
+	r := NewJsonReportBuilder(slog.New(slog.DiscardHandler), config.Config{})
 	r.SubmitDeal(market.Deal{
 		Symbol:    "BTC",
 		Qty:       decimal.NewFromInt(1),
@@ -75,6 +112,30 @@ func TestSubmitDeal_divideByZero(t *testing.T) {
 	assert.JSONEq(t, `
 {
 	"total_gain": "100",
+	"metrics": {
+		"trades": 1,
+		"win_rate": 1,
+		"avg_win": "100",
+		"avg_loss": "0",
+		"expectancy": "100",
+		"max_drawdown": 0,
+		"max_drawdown_duration": "0s",
+		"exposure": 0,
+		"avg_holding_time": "0s"
+	},
+	"symbols": {
+		"BTC": {
+			"trades": 1,
+			"win_rate": 1,
+			"avg_win": "100",
+			"avg_loss": "0",
+			"expectancy": "100",
+			"max_drawdown": 0,
+			"max_drawdown_duration": "0s",
+			"exposure": 0,
+			"avg_holding_time": "0s"
+		}
+	},
 	"deals": {
 		"BTC": [{
 			"spend": "0",
@@ -83,3 +144,61 @@ func TestSubmitDeal_divideByZero(t *testing.T) {
 	}
 }`, buff.String())
 }
+
+func TestWrite_openPositions(t *testing.T) {
+	r := NewJsonReportBuilder(slog.New(slog.DiscardHandler), config.Config{})
+	r.SubmitOpenPosition(market.Position{
+		Asset:      market.NewAsset("BTC", 1),
+		EntryPrice: decimal.NewFromInt(10),
+		Qty:        decimal.NewFromInt(10),
+		Price:      decimal.NewFromInt(100),
+		OpenTime:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, market.Bar{
+		Time:  time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Close: decimal.NewFromInt(8),
+	})
+
+	var buff bytes.Buffer
+	require.NoError(t, r.Write(&buff))
+
+	assert.JSONEq(t, `
+{
+	"unrealized_gain": "-20",
+	"open_positions": {
+		"BTC": {
+			"open_time": "2024-01-01T00:00:00Z",
+			"time": "2024-01-02T00:00:00Z",
+			"entry_price": "10",
+			"price": "8",
+			"qty": "10",
+			"spend": "100",
+			"value": "80",
+			"gain": "-20",
+			"gain_pct": -0.2
+		}
+	}
+}`, buff.String())
+}
+
+func TestMergeReports(t *testing.T) {
+	first := JsonReport{Deals: map[string][]JsonDeal{
+		"BTC": {{BuyTime: time.Unix(0, 0), SellTime: time.Unix(60, 0), Spend: "100", Gain: "20"}},
+	}}
+	second := JsonReport{Deals: map[string][]JsonDeal{
+		"BTC": {{BuyTime: time.Unix(120, 0), SellTime: time.Unix(180, 0), Spend: "100", Gain: "-10"}},
+		"ETH": {{BuyTime: time.Unix(120, 0), SellTime: time.Unix(240, 0), Spend: "200", Gain: "30"}},
+	}}
+
+	cfg := config.Config{Strategies: map[string]config.Strategy{"BTC": {Budget: 1000}, "ETH": {Budget: 1000}}}
+	r := MergeReports(slog.New(slog.DiscardHandler), cfg, first, second)
+
+	assert.Equal(t, "40", r.TotalGain)
+	assert.InDelta(t, 0.1, r.TotalGainPct, 1e-9)
+	require.NotNil(t, r.Metrics)
+	assert.Equal(t, 3, r.Metrics.Trades)
+	require.Len(t, r.Deals["BTC"], 2)
+	assert.Equal(t, "20", r.Deals["BTC"][0].Gain)
+	assert.Equal(t, "-10", r.Deals["BTC"][1].Gain)
+	assert.Equal(t, time.Unix(120, 0), r.Deals["BTC"][1].BuyTime)
+	assert.Equal(t, 1, r.Symbols["ETH"].Trades)
+}
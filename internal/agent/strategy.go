@@ -2,11 +2,13 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/gamma-omg/trading-bot/internal/config"
 	"github.com/gamma-omg/trading-bot/internal/indicator"
@@ -18,6 +20,7 @@ import (
 
 type tradingIndicator interface {
 	GetSignal() (indicator.Signal, error)
+	Trace() indicator.SignalTrace
 	DrawDebug(d *indicator.DebugPlot) error
 }
 
@@ -36,7 +39,15 @@ type account interface {
 
 type reportBuilder interface {
 	SubmitDeal(d market.Deal)
+	SubmitEquity(symbol string, t time.Time, equity decimal.Decimal)
+	SubmitPrice(symbol string, t time.Time, price decimal.Decimal)
+	SubmitOpenPosition(p market.Position, last market.Bar)
 	Write(w io.Writer) error
+	WriteEquity(prefix string) error
+}
+
+type tradingJournal interface {
+	Record(r JournalRecord) error
 }
 
 type positionValidator interface {
@@ -53,10 +64,12 @@ type TradingStrategy struct {
 	posValidator positionValidator
 	acc          account
 	report       reportBuilder
+	journal      tradingJournal
 	position     *market.Position
+	realized     decimal.Decimal
 }
 
-func newTradingStrategy(asset *market.Asset, cfg config.Strategy, indicator tradingIndicator, validator positionValidator, positionManager positionManager, acc account, report reportBuilder, log *slog.Logger) *TradingStrategy {
+func newTradingStrategy(asset *market.Asset, cfg config.Strategy, indicator tradingIndicator, validator positionValidator, positionManager positionManager, acc account, report reportBuilder, journal tradingJournal, log *slog.Logger) *TradingStrategy {
 	return &TradingStrategy{
 		log:          log,
 		asset:        asset,
@@ -67,6 +80,7 @@ func newTradingStrategy(asset *market.Asset, cfg config.Strategy, indicator trad
 		posMan:       positionManager,
 		acc:          acc,
 		report:       report,
+		journal:      journal,
 		position:     nil,
 	}
 }
@@ -84,14 +98,21 @@ func (ts *TradingStrategy) Init() error {
 }
 
 func (ts *TradingStrategy) Run(ctx context.Context) error {
+	defer ts.submitEquity()
+
+	rec := ts.newJournalRecord()
 	if ts.position != nil {
 		clz, err := ts.posValidator.NeedClose(ts.position)
 		if err != nil {
 			return fmt.Errorf("failed to validate position: %w", err)
 		}
 		if clz {
-			if err := ts.sell(ctx, 1.0); err != nil {
-				return fmt.Errorf("failed to sell position: %w", err)
+			o, err := ts.sell(ctx, 1.0)
+			o.Reason = JournalReasonValidator
+			rec.Orders = append(rec.Orders, o)
+			rec.Action = JournalActionSell
+			if err != nil {
+				return ts.recordFailure(rec, fmt.Errorf("failed to sell position: %w", err))
 			}
 		}
 	}
@@ -101,6 +122,9 @@ func (ts *TradingStrategy) Run(ctx context.Context) error {
 		return fmt.Errorf("failed to get signal from indicator: %w", err)
 	}
 
+	trace := ts.indicator.Trace()
+	rec.Signal = &trace
+
 	if ts.cfg.DebugLevel >= config.DebugAll {
 		if err := ts.drawDebug(s); err != nil {
 			ts.log.Error("failed to create debug plot", slog.String("symbol", ts.asset.Symbol), slog.Any("error", err))
@@ -108,12 +132,15 @@ func (ts *TradingStrategy) Run(ctx context.Context) error {
 	}
 
 	if s.Act == indicator.ActHold {
-		return nil
+		return ts.record(rec)
 	}
 
 	if ts.position == nil && s.Act == indicator.ActBuy && s.Confidence >= ts.cfg.BuyConfidence {
-		if err = ts.buy(ctx, s.Confidence); err != nil {
-			return fmt.Errorf("failed to process buy signal: %w", err)
+		o, err := ts.buy(ctx, s.Confidence)
+		rec.Orders = append(rec.Orders, o)
+		rec.Action = JournalActionBuy
+		if err != nil {
+			return ts.recordFailure(rec, fmt.Errorf("failed to process buy signal: %w", err))
 		}
 
 		if ts.cfg.DebugLevel >= config.DebugBuyOrSell {
@@ -124,8 +151,11 @@ func (ts *TradingStrategy) Run(ctx context.Context) error {
 	}
 
 	if ts.position != nil && s.Act == indicator.ActSell && s.Confidence >= ts.cfg.SellConfidence {
-		if err = ts.sell(ctx, s.Confidence); err != nil {
-			return fmt.Errorf("failed to process sell signal: %w", err)
+		o, err := ts.sell(ctx, s.Confidence)
+		rec.Orders = append(rec.Orders, o)
+		rec.Action = JournalActionSell
+		if err != nil {
+			return ts.recordFailure(rec, fmt.Errorf("failed to process sell signal: %w", err))
 		}
 
 		if ts.cfg.DebugLevel >= config.DebugBuyOrSell {
@@ -135,36 +165,125 @@ func (ts *TradingStrategy) Run(ctx context.Context) error {
 		}
 	}
 
+	return ts.record(rec)
+}
+
+func (ts *TradingStrategy) Finish(ctx context.Context, closePosition bool) error {
+	if ts.position == nil {
+		return nil
+	}
+
+	if closePosition {
+		rec := ts.newJournalRecord()
+		o, err := ts.sell(ctx, 1.0)
+		o.Reason = JournalReasonEndOfRun
+		rec.Orders = append(rec.Orders, o)
+		rec.Action = JournalActionSell
+		if err != nil {
+			return ts.recordFailure(rec, fmt.Errorf("failed to close position at the end of run: %w", err))
+		}
+
+		ts.submitEquity()
+		return ts.record(rec)
+	}
+
+	last, err := ts.asset.GetLastBar()
+	if err != nil {
+		return fmt.Errorf("failed to get last bar for open position: %w", err)
+	}
+
+	ts.report.SubmitOpenPosition(*ts.position, last)
 	return nil
 }
 
-func (ts *TradingStrategy) buy(ctx context.Context, confidence float64) error {
+func (ts *TradingStrategy) buy(ctx context.Context, confidence float64) (JournalOrder, error) {
+	o := JournalOrder{
+		Side:   JournalActionBuy,
+		Reason: JournalReasonSignal,
+	}
+
 	funds, err := ts.getAvailableFunds()
 	if err != nil {
-		return fmt.Errorf("failed to get available funds: %w", err)
+		return o.failed(fmt.Errorf("failed to get available funds: %w", err))
 	}
 
 	size := ts.posScaler.GetSize(funds, confidence)
+	o.Funds = funds.String()
+	o.Size = size.String()
+
 	p, err := ts.posMan.Open(ctx, ts.asset, size)
 	if err != nil {
-		return fmt.Errorf("failed to open position: %w", err)
+		return o.failed(fmt.Errorf("failed to open position: %w", err))
 	}
 
 	ts.position = p
-	return nil
+	o.Fill = buyFill(p)
+	return o, nil
 }
 
-func (ts *TradingStrategy) sell(ctx context.Context, _ float64) error {
+func (ts *TradingStrategy) sell(ctx context.Context, _ float64) (JournalOrder, error) {
+	o := JournalOrder{
+		Side:   JournalActionSell,
+		Reason: JournalReasonSignal,
+		Qty:    ts.position.Qty.String(),
+	}
+
 	d, err := ts.posMan.Close(ctx, ts.position)
 	if err != nil {
-		return fmt.Errorf("failed to sell position: %w", err)
+		return o.failed(fmt.Errorf("failed to sell position: %w", err))
 	}
 
 	ts.report.SubmitDeal(d)
+	ts.realized = ts.realized.Add(dealGain(d))
 	ts.position = nil
+	o.Fill = sellFill(d)
+	return o, nil
+}
+
+func (ts *TradingStrategy) newJournalRecord() JournalRecord {
+	last, _ := ts.asset.GetLastBar()
+
+	rec := newJournalRecord(ts.asset.Symbol, last)
+	rec.BuyConfidence = ts.cfg.BuyConfidence
+	rec.SellConfidence = ts.cfg.SellConfidence
+	return rec
+}
+
+func (ts *TradingStrategy) record(rec JournalRecord) error {
+	if ts.journal == nil {
+		return nil
+	}
+
+	if err := ts.journal.Record(rec); err != nil {
+		return fmt.Errorf("failed to record decision for %s: %w", ts.asset.Symbol, err)
+	}
+
 	return nil
 }
 
+// recordFailure journals the decision with its failed order before returning the error.
+func (ts *TradingStrategy) recordFailure(rec JournalRecord, err error) error {
+	if rerr := ts.record(rec); rerr != nil {
+		return errors.Join(err, rerr)
+	}
+
+	return err
+}
+
+func (ts *TradingStrategy) submitEquity() {
+	last, err := ts.asset.GetLastBar()
+	if err != nil {
+		return
+	}
+
+	equity := decimal.NewFromInt(ts.cfg.Budget).Add(ts.realized)
+	if ts.position != nil {
+		equity = equity.Add(ts.position.Qty.Mul(last.Close)).Sub(ts.position.Price)
+	}
+
+	ts.report.SubmitEquity(ts.asset.Symbol, last.Time, equity)
+}
+
 func (ts *TradingStrategy) getAvailableFunds() (decimal.Decimal, error) {
 	available := decimal.NewFromInt(ts.cfg.Budget)
 	if ts.position != nil {
@@ -36,9 +36,14 @@ func (s *mockPositionScaler) GetSize(budget decimal.Decimal, confidence float64)
 type mockPositionManager struct {
 	positions []*market.Position
 	qtyFunc   func(size decimal.Decimal, symbol string) decimal.Decimal
+	err       error
 }
 
 func (pm *mockPositionManager) Open(_ context.Context, asset *market.Asset, size decimal.Decimal) (*market.Position, error) {
+	if pm.err != nil {
+		return nil, pm.err
+	}
+
 	pos := &market.Position{
 		Asset: asset,
 		Qty:   pm.qtyFunc(size, asset.Symbol),
@@ -67,22 +72,46 @@ func (m *mockIndicator) GetSignal() (indicator.Signal, error) {
 	}, nil
 }
 
+func (m *mockIndicator) Trace() indicator.SignalTrace {
+	return indicator.SignalTrace{
+		Indicator:  "mock",
+		Act:        m.act.String(),
+		Confidence: m.confidence,
+	}
+}
+
 func (m *mockIndicator) DrawDebug(_ *indicator.DebugPlot) error {
 	return nil
 }
 
 type mockReport struct {
-	deals []market.Deal
+	deals     []market.Deal
+	equity    []decimal.Decimal
+	positions []market.Position
 }
 
 func (m *mockReport) SubmitDeal(d market.Deal) {
 	m.deals = append(m.deals, d)
 }
 
+func (m *mockReport) SubmitEquity(_ string, _ time.Time, equity decimal.Decimal) {
+	m.equity = append(m.equity, equity)
+}
+
+func (m *mockReport) SubmitPrice(_ string, _ time.Time, _ decimal.Decimal) {}
+
+func (m *mockReport) SubmitOpenPosition(p market.Position, _ market.Bar) {
+	m.positions = append(m.positions, p)
+}
+
 func (m *mockReport) Write(_ io.Writer) error {
 	return nil
 }
 
+func (m *mockReport) WriteEquity(_ string) error {
+	return nil
+}
+
 type mockPositionValidator struct {
 	needClose bool
 }
@@ -192,6 +221,74 @@ func TestRun_closesInvalidPosition(t *testing.T) {
 	assert.Nil(t, s.position)
 }
 
+func TestRun_submitsEquity(t *testing.T) {
+	asset := market.NewAsset("BTC", 1)
+	asset.Receive(market.Bar{Time: time.Unix(60, 0), Close: decimal.NewFromInt(12)})
+
+	r := &mockReport{}
+	s := TradingStrategy{
+		asset:        asset,
+		log:          slog.Default(),
+		cfg:          config.Strategy{Budget: 1000},
+		posValidator: &mockPositionValidator{},
+		position: &market.Position{
+			Asset: asset,
+			Qty:   decimal.NewFromInt(50),
+			Price: decimal.NewFromInt(500),
+		},
+		realized: decimal.NewFromInt(-100),
+		report:   r,
+		indicator: &mockIndicator{
+			act: indicator.ActHold,
+		},
+	}
+
+	require.NoError(t, s.Run(context.Background()))
+	require.Len(t, r.equity, 1)
+	assert.True(t, decimal.NewFromInt(1000).Equal(r.equity[0]))
+}
+
+func TestFinish(t *testing.T) {
+	tbl := []struct {
+		closePosition bool
+		deals         int
+		positions     int
+	}{
+		{closePosition: false, deals: 0, positions: 1},
+		{closePosition: true, deals: 1, positions: 0},
+	}
+
+	for _, c := range tbl {
+		t.Run(fmt.Sprint(c.closePosition), func(t *testing.T) {
+			asset := market.NewAsset("BTC", 1)
+			asset.Receive(market.Bar{Time: time.Unix(60, 0), Close: decimal.NewFromInt(12)})
+
+			p := &market.Position{Asset: asset, Qty: decimal.NewFromInt(1)}
+			r := &mockReport{}
+			s := TradingStrategy{
+				asset:    asset,
+				posMan:   &mockPositionManager{positions: []*market.Position{p}},
+				position: p,
+				report:   r,
+			}
+
+			require.NoError(t, s.Finish(context.Background(), c.closePosition))
+			assert.Len(t, r.deals, c.deals)
+			assert.Len(t, r.positions, c.positions)
+			assert.Equal(t, c.closePosition, s.position == nil)
+		})
+	}
+}
+
+func TestFinish_noPosition(t *testing.T) {
+	r := &mockReport{}
+	s := TradingStrategy{asset: market.NewAsset("BTC", 1), report: r}
+
+	require.NoError(t, s.Finish(context.Background(), true))
+	assert.Empty(t, r.deals)
+	assert.Empty(t, r.positions)
+}
+
 func TestBuy(t *testing.T) {
 	scaler := &mockPositionScaler{
 		scaleFunc: func(budget decimal.Decimal, confidence float64) decimal.Decimal {
@@ -212,7 +309,8 @@ func TestBuy(t *testing.T) {
 		},
 	}
 
-	require.NoError(t, s.buy(context.Background(), 0.6))
+	_, err := s.buy(context.Background(), 0.6)
+	require.NoError(t, err)
 	assert.Len(t, posMan.positions, 1)
 
 	p := posMan.positions[0]
@@ -232,7 +330,8 @@ func TestSell(t *testing.T) {
 		report:   r,
 	}
 
-	require.NoError(t, s.sell(context.Background(), 0.6))
+	_, err := s.sell(context.Background(), 0.6)
+	require.NoError(t, err)
 
 	assert.ElementsMatch(t, []*market.Position{o}, posMan.positions)
 	assert.Len(t, r.deals, 1)
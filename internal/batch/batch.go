@@ -0,0 +1,257 @@
+package batch
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/agent"
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"golang.org/x/sync/errgroup"
+)
+
+type Job struct {
+	Name   string
+	Config config.Config
+}
+
+type Result struct {
+	Name     string
+	Report   string
+	Duration time.Duration
+	Metrics  map[string]*float64
+	Err      error
+}
+
+type runner func(ctx context.Context, log *slog.Logger, cfg config.Config) (agent.JsonReport, error)
+
+type Batch struct {
+	log     *slog.Logger
+	dir     string
+	workers int
+	run     runner
+}
+
+func NewBatch(log *slog.Logger, dir string, workers int) *Batch {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	return &Batch{
+		log:     log,
+		dir:     dir,
+		workers: workers,
+		run:     runAgent,
+	}
+}
+
+func LoadJobs(paths []string) ([]Job, error) {
+	jobs := make([]Job, 0, len(paths))
+	seen := map[string]int{}
+	for _, path := range paths {
+		cfg, err := config.ReadFromFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", path, err)
+		}
+
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			name = fmt.Sprintf("%s_%d", name, n)
+		}
+
+		jobs = append(jobs, Job{Name: name, Config: *cfg})
+	}
+
+	return jobs, nil
+}
+
+func (b *Batch) Run(ctx context.Context, jobs []Job) ([]Result, error) {
+	results := make([]Result, len(jobs))
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(b.workers)
+	for i, job := range jobs {
+		g.Go(func() error {
+			results[i] = b.runJob(ctx, job)
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			if results[i].Err != nil {
+				b.log.Warn("batch run failed", slog.String("run", job.Name), slog.Any("error", results[i].Err))
+			} else {
+				b.log.Info("batch run finished", slog.String("run", job.Name), slog.Duration("duration", results[i].Duration))
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return results, fmt.Errorf("batch interrupted: %w", err)
+	}
+
+	return results, nil
+}
+
+func (b *Batch) runJob(ctx context.Context, job Job) Result {
+	start := time.Now()
+	res := Result{Name: job.Name}
+
+	cfg, err := b.isolate(job)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.Report = cfg.Report
+
+	report, err := b.run(ctx, b.log.With(slog.String("run", job.Name)), cfg)
+	res.Duration = time.Since(start)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	res.Metrics = agent.ReportMetrics(report)
+	return res
+}
+
+func (b *Batch) isolate(job Job) (config.Config, error) {
+	cfg := job.Config
+	emu, ok := cfg.PlatformRef.Platform.(config.Emulator)
+	if !ok {
+		return cfg, errors.New("batch runs require the emulator platform")
+	}
+	emu.Replay = nil
+	cfg.PlatformRef.Platform = emu
+
+	dir := filepath.Join(b.dir, job.Name)
+	ext := filepath.Ext(cfg.Report)
+	if ext == "" {
+		ext = ".json"
+	}
+	cfg.Report = filepath.Join(dir, "report"+ext)
+	if cfg.Journal != "" {
+		cfg.Journal = filepath.Join(dir, "journal.jsonl")
+	}
+
+	strategies := make(map[string]config.Strategy, len(cfg.Strategies))
+	for symbol, s := range cfg.Strategies {
+		s.DebugDir = filepath.Join(dir, "debug", symbol)
+		if s.DataDump != "" {
+			s.DataDump = filepath.Join(dir, "dump", symbol+".csv")
+		}
+		strategies[symbol] = s
+	}
+	cfg.Strategies = strategies
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return cfg, fmt.Errorf("failed to create run directory: %w", err)
+	}
+
+	return cfg, nil
+}
+
+func runAgent(ctx context.Context, log *slog.Logger, cfg config.Config) (agent.JsonReport, error) {
+	r, err := agent.NewReportBuilder(log, cfg)
+	if err != nil {
+		return agent.JsonReport{}, fmt.Errorf("failed to create report builder: %w", err)
+	}
+
+	a, err := agent.NewTradingAgent(log, cfg, r)
+	if err != nil {
+		return agent.JsonReport{}, fmt.Errorf("failed to create trading agent: %w", err)
+	}
+
+	if err := a.Run(ctx); err != nil {
+		return agent.JsonReport{}, fmt.Errorf("failed to run backtest: %w", err)
+	}
+
+	report, ok := r.(interface{ Report() agent.JsonReport })
+	if !ok {
+		return agent.JsonReport{}, errors.New("report builder does not expose results")
+	}
+
+	return report.Report(), nil
+}
+
+var summaryMetrics = []string{"total_gain", "total_gain_pct", "trades", "win_rate", "profit_factor", "max_drawdown", "sharpe", "sortino", "excess_return"}
+
+func WriteSummaryCsv(w io.Writer, results []Result) error {
+	cw := csv.NewWriter(w)
+
+	header := append([]string{"run", "report", "duration"}, agent.ReportMetricNames...)
+	header = append(header, "error")
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("failed to write summary header: %w", err)
+	}
+
+	for _, r := range results {
+		row := []string{r.Name, r.Report, r.Duration.Round(time.Millisecond).String()}
+		for _, name := range agent.ReportMetricNames {
+			v := ""
+			if m := r.Metrics[name]; m != nil {
+				v = strconv.FormatFloat(*m, 'g', -1, 64)
+			}
+			row = append(row, v)
+		}
+
+		errMsg := ""
+		if r.Err != nil {
+			errMsg = r.Err.Error()
+		}
+		row = append(row, errMsg)
+
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("failed to write summary row: %w", err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+
+	return nil
+}
+
+func WriteSummaryTable(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "run\t%s\terror\t\n", strings.Join(summaryMetrics, "\t"))
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t", r.Name)
+		for _, name := range summaryMetrics {
+			v := "-"
+			if m := r.Metrics[name]; m != nil {
+				v = strconv.FormatFloat(*m, 'g', 6, 64)
+			}
+			fmt.Fprintf(tw, "%s\t", v)
+		}
+
+		errMsg := ""
+		if r.Err != nil {
+			errMsg = r.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t\n", errMsg)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,250 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/agent"
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func readConfig(t *testing.T, dir string, budget int) config.Config {
+	t.Helper()
+
+	data := writeFile(t, dir, "btc.csv", "")
+	cfg, err := config.Read(strings.NewReader(fmt.Sprintf(`
+report: report.html
+journal: journal.jsonl
+strategies:
+  BTC:
+    budget: %d
+    take_profit: 1.1
+    position_scale: 1
+    market_buffer: 10
+    debug_dir: debug
+    data_dump: dump.csv
+    indicator:
+      rsi:
+        period: 7
+        overbought: 0.6
+  ETH:
+    budget: 100
+    take_profit: 1.1
+    position_scale: 1
+    market_buffer: 10
+    debug_dir: debug
+    indicator:
+      rsi:
+        period: 7
+        overbought: 0.6
+platform:
+  emulator:
+    data:
+      BTC: %s
+      ETH: %s
+    end: 2025-01-02T00:00:00Z
+    replay:
+      speed: 2
+`, budget, data, data)))
+	require.NoError(t, err)
+	return *cfg
+}
+
+func TestLoadJobs(t *testing.T) {
+	dir := t.TempDir()
+	cfg := "strategies:\n  BTC:\n    budget: 100\n"
+	a := writeFile(t, dir, "a.yaml", cfg)
+	b := writeFile(t, dir, "x/a.yaml", cfg)
+	c := writeFile(t, dir, "c.yml", cfg)
+
+	jobs, err := LoadJobs([]string{a, b, c})
+	require.NoError(t, err)
+
+	var names []string
+	for _, j := range jobs {
+		names = append(names, j.Name)
+		assert.Equal(t, int64(100), j.Config.Strategies["BTC"].Budget)
+	}
+	assert.Equal(t, []string{"a", "a_2", "c"}, names)
+
+	_, err = LoadJobs([]string{filepath.Join(dir, "missing.yaml")})
+	assert.Error(t, err)
+}
+
+func TestBatch_isolate(t *testing.T) {
+	dir := t.TempDir()
+	b := NewBatch(slog.New(slog.DiscardHandler), dir, 1)
+
+	cfg := readConfig(t, t.TempDir(), 100)
+
+	got, err := b.isolate(Job{Name: "run", Config: cfg})
+	require.NoError(t, err)
+
+	runDir := filepath.Join(dir, "run")
+	assert.DirExists(t, runDir)
+	assert.Equal(t, filepath.Join(runDir, "report.html"), got.Report)
+	assert.Equal(t, filepath.Join(runDir, "journal.jsonl"), got.Journal)
+	assert.Equal(t, filepath.Join(runDir, "debug", "BTC"), got.Strategies["BTC"].DebugDir)
+	assert.Equal(t, filepath.Join(runDir, "debug", "ETH"), got.Strategies["ETH"].DebugDir)
+	assert.Equal(t, filepath.Join(runDir, "dump", "BTC.csv"), got.Strategies["BTC"].DataDump)
+	assert.Empty(t, got.Strategies["ETH"].DataDump)
+	assert.Nil(t, got.PlatformRef.Platform.(config.Emulator).Replay)
+
+	assert.Equal(t, "debug", cfg.Strategies["BTC"].DebugDir, "job config must not be modified")
+
+	_, err = b.isolate(Job{Name: "live", Config: config.Config{PlatformRef: config.PlatformReference{Platform: config.Alpaca{}}}})
+	assert.Error(t, err)
+
+	_, err = b.isolate(Job{Name: "invalid", Config: readConfig(t, t.TempDir(), 0)})
+	var invalid config.ValidationError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, []config.Violation{
+		{Path: "strategies.BTC.budget", Line: 6, Message: "must be positive, got 0"},
+	}, []config.Violation(invalid))
+	assert.NoDirExists(t, filepath.Join(dir, "invalid"))
+}
+
+func TestBatch_Run(t *testing.T) {
+	dir := t.TempDir()
+	b := NewBatch(slog.New(slog.DiscardHandler), dir, 2)
+
+	var mu sync.Mutex
+	reports := map[string]bool{}
+	b.run = func(_ context.Context, _ *slog.Logger, cfg config.Config) (agent.JsonReport, error) {
+		mu.Lock()
+		reports[cfg.Report] = true
+		mu.Unlock()
+
+		budget := cfg.Strategies["BTC"].Budget
+		if budget < 10 {
+			return agent.JsonReport{}, errors.New("no budget")
+		}
+		return agent.JsonReport{TotalGain: fmt.Sprint(budget / 10)}, nil
+	}
+
+	data := t.TempDir()
+	jobs := []Job{
+		{Name: "small", Config: readConfig(t, data, 100)},
+		{Name: "large", Config: readConfig(t, data, 1000)},
+		{Name: "broken", Config: readConfig(t, data, 1)},
+		{Name: "invalid", Config: readConfig(t, data, 0)},
+	}
+
+	results, err := b.Run(context.Background(), jobs)
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	assert.Len(t, reports, 3)
+
+	assert.Equal(t, "small", results[0].Name)
+	assert.Equal(t, 10.0, *results[0].Metrics["total_gain"])
+	assert.Equal(t, 100.0, *results[1].Metrics["total_gain"])
+	assert.EqualError(t, results[2].Err, "no budget")
+	assert.ErrorContains(t, results[3].Err, "line 6: strategies.BTC.budget: must be positive, got 0")
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteSummaryCsv(&buf, results))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 5)
+	assert.Equal(t, "run", rows[0][0])
+	assert.Equal(t, "total_gain", rows[0][3])
+	assert.Equal(t, "100", rows[2][3])
+	assert.Equal(t, "no budget", rows[3][len(rows[3])-1])
+
+	buf.Reset()
+	require.NoError(t, WriteSummaryTable(&buf, results))
+	assert.Contains(t, buf.String(), "large")
+	assert.Contains(t, buf.String(), "no budget")
+}
+
+func TestBatch_RunEmulator(t *testing.T) {
+	dir := t.TempDir()
+
+	var data strings.Builder
+	data.WriteString("timestamp,open,high,low,close,volume\n")
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 500 {
+		p := 100 + 10*math.Sin(float64(i)/15)
+		fmt.Fprintf(&data, "%d,%.4f,%.4f,%.4f,%.4f,1\n", start.Add(time.Duration(i)*time.Minute).Unix(), p, p+0.5, p-0.5, p)
+	}
+	dataFile := writeFile(t, dir, "btc.csv", data.String())
+
+	cfg := func(buy float64) string {
+		return fmt.Sprintf(`
+strategies:
+  BTC:
+    budget: 1000
+    buy_confidence: %g
+    sell_confidence: 0.1
+    take_profit: 1.05
+    stop_loss: 0.95
+    position_scale: 1
+    market_buffer: 50
+    debug_dir: debug
+    debug_level: 1
+    debug_window: 30
+    indicator:
+      rsi:
+        period: 7
+        overbought: 0.6
+report: report.json
+platform:
+  emulator:
+    data:
+      BTC: %s
+    start: 2024-12-31T00:00:00Z
+    end: 2025-01-02T00:00:00Z
+    balance: 1000
+    close_positions: true
+`, buy, dataFile)
+	}
+
+	paths := []string{
+		writeFile(t, dir, "one.yaml", cfg(0.1)),
+		writeFile(t, dir, "two.yaml", cfg(0.1)),
+		writeFile(t, dir, "strict.yaml", cfg(0.99)),
+	}
+	jobs, err := LoadJobs(paths)
+	require.NoError(t, err)
+
+	out := filepath.Join(dir, "out")
+	results, err := NewBatch(slog.New(slog.DiscardHandler), out, 3).Run(context.Background(), jobs)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	for _, r := range results {
+		require.NoError(t, r.Err, r.Name)
+		assert.FileExists(t, filepath.Join(out, r.Name, "report.json"))
+	}
+
+	require.NotNil(t, results[0].Metrics["trades"])
+	assert.Greater(t, *results[0].Metrics["trades"], 0.0)
+	assert.Equal(t, results[0].Metrics, results[1].Metrics)
+	assert.NotEqual(t, results[0].Metrics, results[2].Metrics)
+
+	entries, err := os.ReadDir(filepath.Join(out, "one", "debug", "BTC"))
+	require.NoError(t, err)
+	assert.NotEmpty(t, entries)
+}
@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"text/tabwriter"
+)
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(ctx context.Context, a *app, fs *flag.FlagSet, args []string) error
+}
+
+var commands = []command{
+	{name: "run", args: "[flags]", summary: "run the trading agent on the configured platform", run: runCmd},
+	{name: "backtest", args: "[flags]", summary: "run a backtest on the emulator platform", run: backtestCmd},
+	{name: "validate", args: "[flags]", summary: "check a config and report all problems", run: validateCmd},
+	{name: "report", args: "[flags] report.json", summary: "render a saved report as a table, json or html", run: reportCmd},
+	{name: "diff", args: "[flags] base.json other.json [other.json...]", summary: "compare saved reports", run: diffCmd},
+	{name: "montecarlo", args: "-capital amount [flags] report.json", summary: "estimate the robustness of a report's trades", run: monteCarloCmd},
+	{name: "download", args: "[flags]", summary: "download historical bars from Alpaca", run: downloadCmd},
+	{name: "check", args: "[flags]", summary: "check emulator data files for quality issues", run: checkCmd},
+	{name: "cache", args: "[flags] build|verify", summary: "build or verify the emulator bar cache", run: cacheCmd},
+	{name: "synth", args: "[flags]", summary: "generate synthetic market data", run: synthCmd},
+	{name: "optimize", args: "[flags]", summary: "search strategy parameters over backtests", run: optimizeCmd},
+	{name: "batch", args: "[flags] config.yaml [config.yaml...]", summary: "run isolated backtests concurrently", run: batchCmd},
+}
+
+type exitCode int
+
+func (c exitCode) Error() string {
+	return fmt.Sprintf("exit status %d", int(c))
+}
+
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+type app struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (a *app) logger(verbose bool) *slog.Logger {
+	level := slog.LevelWarn
+	if verbose {
+		level = slog.LevelInfo
+	}
+
+	return slog.New(slog.NewTextHandler(a.stderr, &slog.HandlerOptions{Level: level}))
+}
+
+func Main(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	a := &app{stdout: stdout, stderr: stderr}
+
+	if len(args) == 0 {
+		a.usage()
+		return 2
+	}
+
+	name := args[0]
+	if name == "help" || name == "-h" || name == "--help" || name == "-help" {
+		a.usage()
+		return 0
+	}
+
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command: %s\n\n", name)
+		a.usage()
+		return 2
+	}
+
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: trading-bot %s %s\n\n%s\n", cmd.name, cmd.args, cmd.summary)
+		if hasFlags(fs) {
+			fmt.Fprintln(stderr)
+			fs.PrintDefaults()
+		}
+	}
+
+	err := cmd.run(ctx, a, fs, args[1:])
+
+	var code exitCode
+	var usage usageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &code):
+		return int(code)
+	case errors.As(err, &usage):
+		fmt.Fprintf(stderr, "%s\n\n", usage)
+		fs.Usage()
+		return 2
+	case errors.Is(err, errFlags):
+		return 2
+	default:
+		fmt.Fprintf(stderr, "%s: %v\n", cmd.name, err)
+		return 1
+	}
+}
+
+var errFlags = errors.New("invalid flags")
+
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errFlags
+	}
+
+	return nil
+}
+
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+
+	return command{}, false
+}
+
+func hasFlags(fs *flag.FlagSet) bool {
+	found := false
+	fs.VisitAll(func(*flag.Flag) { found = true })
+	return found
+}
+
+func (a *app) usage() {
+	fmt.Fprintln(a.stderr, "usage: trading-bot <command> [flags] [args]")
+	fmt.Fprintln(a.stderr, "\ncommands:")
+
+	tw := tabwriter.NewWriter(a.stderr, 0, 4, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", c.name, c.summary)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(a.stderr, "\nrun 'trading-bot <command> -h' for the flags of a command")
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
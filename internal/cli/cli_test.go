@@ -0,0 +1,323 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/agent"
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func run(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	code := Main(context.Background(), args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func writeTestFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func writeEmulatorConfig(t *testing.T, dir string) string {
+	t.Helper()
+
+	var data strings.Builder
+	data.WriteString("timestamp,open,high,low,close,volume\n")
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 3 * 24 * 60 {
+		p := 100 + 10*math.Sin(float64(i)/15)
+		fmt.Fprintf(&data, "%d,%.4f,%.4f,%.4f,%.4f,1\n", start.Add(time.Duration(i)*time.Minute).Unix(), p, p+0.5, p-0.5, p)
+	}
+	dataFile := writeTestFile(t, dir, "btc.csv", data.String())
+
+	return writeTestFile(t, dir, "config.yaml", fmt.Sprintf(`
+strategies:
+  BTC:
+    budget: 1000
+    buy_confidence: 0.1
+    sell_confidence: 0.1
+    take_profit: 1.05
+    stop_loss: 0.95
+    position_scale: 1
+    market_buffer: 50
+    debug_dir: %[1]s
+    indicator:
+      rsi:
+        period: 7
+        overbought: 0.6
+report: %[1]s/report.json
+platform:
+  emulator:
+    data:
+      BTC: %[2]s
+    start: 2024-12-31T00:00:00Z
+    end: 2025-01-04T00:00:00Z
+    balance: 1000
+    close_positions: true
+`, filepath.Join(dir, "debug"), dataFile))
+}
+
+func TestMain_usage(t *testing.T) {
+	code, _, stderr := run(t)
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "backtest")
+
+	code, _, stderr = run(t, "help")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stderr, "validate")
+
+	code, _, stderr = run(t, "nope")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "unknown command: nope")
+
+	code, _, stderr = run(t, "report", "-h")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stderr, "usage: trading-bot report")
+
+	code, _, _ = run(t, "report", "-bogus")
+	assert.Equal(t, 2, code)
+
+	code, _, stderr = run(t, "diff", "only.json")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "expected a base report")
+
+	t.Setenv("CONFIG", "")
+	code, _, stderr = run(t, "backtest")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "no config file")
+}
+
+func TestConfigFlags_apply(t *testing.T) {
+	base := func() *config.Config {
+		return &config.Config{
+			Report: "report.json",
+			Strategies: map[string]config.Strategy{
+				"BTC": {Budget: 100},
+				"ETH": {Budget: 200},
+				"SOL": {Budget: 300},
+			},
+			PlatformRef: config.PlatformReference{Platform: config.Emulator{
+				Start:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+				End:     time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+				Balance: 1000,
+			}},
+		}
+	}
+
+	tbl := []struct {
+		name  string
+		flags configFlags
+		check func(t *testing.T, cfg *config.Config)
+		err   string
+	}{
+		{
+			name:  "no overrides",
+			check: func(t *testing.T, cfg *config.Config) { assert.Equal(t, base(), cfg) },
+		},
+		{
+			name:  "dates and balance",
+			flags: configFlags{start: "2025-02-01", end: "2025-03-01T12:00:00Z", balance: "5000.5"},
+			check: func(t *testing.T, cfg *config.Config) {
+				emu := cfg.PlatformRef.Platform.(config.Emulator)
+				assert.Equal(t, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), emu.Start)
+				assert.Equal(t, time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC), emu.End)
+				assert.Equal(t, 5000.5, emu.Balance)
+				assert.Len(t, cfg.Strategies, 3)
+			},
+		},
+		{
+			name:  "symbols and report",
+			flags: configFlags{symbols: "SOL, BTC", report: "other.html"},
+			check: func(t *testing.T, cfg *config.Config) {
+				assert.Equal(t, map[string]config.Strategy{"BTC": {Budget: 100}, "SOL": {Budget: 300}}, cfg.Strategies)
+				assert.Equal(t, "other.html", cfg.Report)
+			},
+		},
+		{name: "unknown symbol", flags: configFlags{symbols: "DOGE"}, err: "no strategy for symbol DOGE (configured: BTC, ETH, SOL)"},
+		{name: "invalid start", flags: configFlags{start: "yesterday"}, err: "invalid start time"},
+		{name: "negative balance", flags: configFlags{balance: "-1"}, err: "invalid balance: -1"},
+	}
+
+	for _, c := range tbl {
+		t.Run(c.name, func(t *testing.T) {
+			cfg := base()
+			err := c.flags.apply(cfg)
+			if c.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), c.err)
+				return
+			}
+
+			require.NoError(t, err)
+			c.check(t, cfg)
+		})
+	}
+
+	alpaca := &config.Config{PlatformRef: config.PlatformReference{Platform: config.Alpaca{}}}
+	assert.Error(t, (&configFlags{balance: "10"}).apply(alpaca))
+	assert.NoError(t, (&configFlags{report: "r.json"}).apply(alpaca))
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	path := writeEmulatorConfig(t, dir)
+
+	code, stdout, _ := run(t, "validate", "-config", path)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "is valid")
+
+	broken := writeTestFile(t, dir, "broken.yaml", `
+strategies:
+  BTC:
+    budget: 0
+  ETH:
+    budget: 100
+report_format: xml
+platform:
+  emulator:
+    data:
+      BTC: missing.csv
+`)
+	code, stdout, _ = run(t, "validate", "-config", broken, "-balance", "abc")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stdout, "invalid balance: abc")
+	assert.Contains(t, stdout, "line 4: strategies.BTC.budget: must be positive, got 0")
+	assert.Contains(t, stdout, "line 5: strategies.ETH.indicator: an indicator is required")
+	assert.Contains(t, stdout, "line 7: report_format: unknown report format \"xml\"")
+	assert.Contains(t, stdout, "line 11: platform.emulator.data.BTC: no files match missing.csv")
+	assert.Contains(t, stdout, "line 10: platform.emulator.data: no data for strategy ETH")
+	assert.Contains(t, stdout, "14 problems found")
+
+	code, _, stderr := run(t, "backtest", "-config", broken)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "invalid config:\nline 3: strategies.BTC.take_profit")
+
+	code, stdout, _ = run(t, "validate", "-config", writeTestFile(t, dir, "syntax.yaml", "strategies: ["))
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stdout, "unable to parse config file")
+}
+
+func TestSynth(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "btc.csv")
+	path := writeTestFile(t, dir, "synthetic.yaml", fmt.Sprintf(`
+synthetic:
+  seed: 1
+  start: 2025-01-01T00:00:00Z
+  interval: 1m
+  bars: 10
+  series:
+    BTC:
+      path: %s
+      price: 100
+      volatility: 0.5
+`, out))
+
+	code, stdout, _ := run(t, "validate", "-config", path)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "is valid")
+
+	code, stdout, stderr := run(t, "synth", "-config", path)
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, "BTC: 10 bars written")
+	assert.FileExists(t, out)
+
+	code, _, stderr = run(t, "run", "-config", path)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "at least one strategy is required")
+
+	code, _, stderr = run(t, "synth", "-config", writeTestFile(t, dir, "empty.yaml", "synthetic:\n  interval: 1m\n"))
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "line 1: synthetic.bars: must be positive, got 0")
+}
+
+func TestBacktest(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CONFIG", writeEmulatorConfig(t, dir))
+
+	full := filepath.Join(dir, "full.json")
+	code, stdout, stderr := run(t, "backtest", "-q", "-report", full)
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, "total_gain")
+
+	short := filepath.Join(dir, "short.json")
+	code, _, stderr = run(t, "backtest", "-q", "-report", short, "-end", "2025-01-02")
+	require.Equal(t, 0, code, stderr)
+
+	fullReport, err := agent.ReadJsonReport(full)
+	require.NoError(t, err)
+	shortReport, err := agent.ReadJsonReport(short)
+	require.NoError(t, err)
+	require.NotNil(t, fullReport.Metrics)
+	require.NotNil(t, shortReport.Metrics)
+	assert.Less(t, shortReport.Metrics.Trades, fullReport.Metrics.Trades)
+
+	code, _, _ = run(t, "diff", "-fail", full, full)
+	assert.Equal(t, 0, code)
+	code, _, _ = run(t, "diff", "-fail", full, short)
+	assert.Equal(t, 1, code)
+
+	code, _, stderr = run(t, "backtest", "-symbol", "ETH")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "no strategy for symbol ETH")
+}
+
+func TestReport(t *testing.T) {
+	dir := t.TempDir()
+	report := agent.JsonReport{
+		TotalGain:    "20",
+		TotalGainPct: 0.02,
+		Metrics:      &agent.JsonMetrics{Trades: 1, WinRate: 1},
+		Deals: map[string][]agent.JsonDeal{"BTC": {{
+			BuyTime:  time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			SellTime: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+			Spend:    "1000",
+			Gain:     "20",
+			GainPct:  0.02,
+		}}},
+	}
+	data, err := json.Marshal(report)
+	require.NoError(t, err)
+	path := writeTestFile(t, dir, "report.json", string(data))
+
+	code, stdout, _ := run(t, "report", path)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "total_gain ")
+	assert.Contains(t, stdout, "BTC")
+
+	code, stdout, _ = run(t, "report", "-format", "json", path)
+	assert.Equal(t, 0, code)
+	var decoded agent.JsonReport
+	require.NoError(t, json.Unmarshal([]byte(stdout), &decoded))
+	assert.Equal(t, report.TotalGain, decoded.TotalGain)
+
+	html := filepath.Join(dir, "report.html")
+	code, _, _ = run(t, "report", "-format", "html", "-o", html, path)
+	assert.Equal(t, 0, code)
+	content, err := os.ReadFile(html)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "<h2>Trades</h2>")
+
+	code, _, _ = run(t, "report", "-format", "xml", path)
+	assert.Equal(t, 2, code)
+
+	code, _, stderr := run(t, "report", filepath.Join(dir, "missing.json"))
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "report:")
+}
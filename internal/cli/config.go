@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/config"
+)
+
+type configFlags struct {
+	path    string
+	start   string
+	end     string
+	balance string
+	symbols string
+	report  string
+}
+
+func (c *configFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.path, "config", os.Getenv("CONFIG"), "config file (defaults to $CONFIG)")
+	fs.StringVar(&c.start, "start", "", "override the emulator start time (RFC3339 or YYYY-MM-DD)")
+	fs.StringVar(&c.end, "end", "", "override the emulator end time (RFC3339 or YYYY-MM-DD)")
+	fs.StringVar(&c.balance, "balance", "", "override the emulator starting balance")
+	fs.StringVar(&c.symbols, "symbol", "", "comma separated list of strategies to keep, e.g. BTC/USD,ETH/USD")
+	fs.StringVar(&c.report, "report", "", "override the report file")
+}
+
+func (c *configFlags) load() (*config.Config, error) {
+	if c.path == "" {
+		return nil, usageError("no config file: pass -config or set CONFIG")
+	}
+
+	cfg, err := config.ReadFromFile(c.path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.apply(cfg); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (c *configFlags) apply(cfg *config.Config) error {
+	if c.report != "" {
+		cfg.Report = c.report
+	}
+
+	if c.symbols != "" {
+		strategies := make(map[string]config.Strategy)
+		for _, symbol := range splitList(c.symbols) {
+			s, ok := cfg.Strategies[symbol]
+			if !ok {
+				return fmt.Errorf("no strategy for symbol %s (configured: %s)", symbol, strings.Join(slices.Sorted(maps.Keys(cfg.Strategies)), ", "))
+			}
+			strategies[symbol] = s
+		}
+		cfg.Strategies = strategies
+	}
+
+	if c.start == "" && c.end == "" && c.balance == "" {
+		return nil
+	}
+
+	emu, ok := cfg.PlatformRef.Platform.(config.Emulator)
+	if !ok {
+		return errors.New("-start, -end and -balance require the emulator platform")
+	}
+
+	if c.start != "" {
+		t, err := parseTime(c.start)
+		if err != nil {
+			return fmt.Errorf("invalid start time: %w", err)
+		}
+		emu.Start = t
+	}
+
+	if c.end != "" {
+		t, err := parseTime(c.end)
+		if err != nil {
+			return fmt.Errorf("invalid end time: %w", err)
+		}
+		emu.End = t
+	}
+
+	if c.balance != "" {
+		b, err := strconv.ParseFloat(c.balance, 64)
+		if err != nil || b < 0 {
+			return fmt.Errorf("invalid balance: %s", c.balance)
+		}
+		emu.Balance = b
+	}
+
+	cfg.PlatformRef.Platform = emu
+	return nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/gamma-omg/trading-bot/internal/platform/alpaca"
+	"github.com/gamma-omg/trading-bot/internal/platform/emulator"
+	"github.com/gamma-omg/trading-bot/internal/synth"
+)
+
+func downloadCmd(ctx context.Context, a *app, fs *flag.FlagSet, args []string) error {
+	configPath := fs.String("config", os.Getenv("CONFIG"), "optional config with Alpaca credentials (defaults to $CONFIG)")
+	symbols := fs.String("symbols", "", "comma separated list of symbols, e.g. BTC/USD,ETH/USD")
+	start := fs.String("start", "", "start time (RFC3339 or YYYY-MM-DD)")
+	end := fs.String("end", "", "end time (RFC3339 or YYYY-MM-DD), defaults to now")
+	timeframe := fs.String("timeframe", "1Min", "bar timeframe, e.g. 1Min, 15Min, 1Hour, 1Day")
+	dir := fs.String("dir", "data", "output directory")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if *symbols == "" || *start == "" {
+		return usageError("-symbols and -start are required")
+	}
+
+	startTime, err := parseTime(*start)
+	if err != nil {
+		return fmt.Errorf("invalid start time: %w", err)
+	}
+
+	endTime := time.Now()
+	if *end != "" {
+		if endTime, err = parseTime(*end); err != nil {
+			return fmt.Errorf("invalid end time: %w", err)
+		}
+	}
+
+	tf, err := alpaca.ParseTimeFrame(*timeframe)
+	if err != nil {
+		return err
+	}
+
+	var cfg config.Alpaca
+	if *configPath != "" {
+		c, err := config.ReadFromFile(*configPath)
+		if err != nil {
+			return err
+		}
+		if p, ok := c.PlatformRef.Platform.(config.Alpaca); ok {
+			cfg = p
+		}
+	}
+
+	if err := os.MkdirAll(*dir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	log := a.logger(true)
+	d := alpaca.NewDownloader(log, cfg)
+	for _, symbol := range splitList(*symbols) {
+		path := filepath.Join(*dir, strings.ReplaceAll(symbol, "/", "")+".csv")
+
+		n, err := d.Download(ctx, alpaca.DownloadRequest{
+			Symbol:    symbol,
+			Start:     startTime,
+			End:       endTime,
+			TimeFrame: tf,
+			Path:      path,
+		})
+		if err != nil {
+			return fmt.Errorf("download of %s stopped after %d bars: %w", symbol, n, err)
+		}
+
+		log.Info("download complete", slog.String("symbol", symbol), slog.String("path", path), slog.Int("bars", n))
+	}
+
+	return nil
+}
+
+func loadEmulator(cf *configFlags, name string) (config.Emulator, error) {
+	cfg, err := cf.load()
+	if err != nil {
+		return config.Emulator{}, err
+	}
+
+	emu, ok := cfg.PlatformRef.Platform.(config.Emulator)
+	if !ok {
+		return config.Emulator{}, fmt.Errorf("%s requires the emulator platform", name)
+	}
+
+	return emu, nil
+}
+
+func checkCmd(ctx context.Context, a *app, fs *flag.FlagSet, args []string) error {
+	var cf configFlags
+	fs.StringVar(&cf.path, "config", os.Getenv("CONFIG"), "config file (defaults to $CONFIG)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	emu, err := loadEmulator(&cf, "data check")
+	if err != nil {
+		return err
+	}
+
+	issues, err := emulator.CheckData(ctx, emu)
+	if err != nil {
+		return err
+	}
+
+	if len(issues) == 0 {
+		fmt.Fprintln(a.stdout, "no issues found")
+		return nil
+	}
+
+	counts := make(map[emulator.IssueKind]int)
+	for _, i := range issues {
+		counts[i.Kind]++
+		fmt.Fprintln(a.stdout, i.Error())
+	}
+
+	for _, kind := range slices.Sorted(maps.Keys(counts)) {
+		fmt.Fprintf(a.stdout, "%s: %d\n", kind, counts[kind])
+	}
+
+	return exitCode(1)
+}
+
+func cacheCmd(ctx context.Context, a *app, fs *flag.FlagSet, args []string) error {
+	var cf configFlags
+	fs.StringVar(&cf.path, "config", os.Getenv("CONFIG"), "config file (defaults to $CONFIG)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return usageError("expected build or verify")
+	}
+
+	var run func(context.Context, config.Emulator) ([]emulator.CacheStatus, error)
+	switch fs.Arg(0) {
+	case "build":
+		run = emulator.BuildCache
+	case "verify":
+		run = emulator.VerifyCache
+	default:
+		return usageError(fmt.Sprintf("unknown cache command: %s", fs.Arg(0)))
+	}
+
+	emu, err := loadEmulator(&cf, "bar cache")
+	if err != nil {
+		return err
+	}
+
+	res, err := run(ctx, emu)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, s := range res {
+		if s.Err != nil {
+			failed++
+			fmt.Fprintf(a.stdout, "FAIL %s %s: %v\n", s.Symbol, s.File, s.Err)
+			continue
+		}
+		fmt.Fprintf(a.stdout, "OK   %s %s -> %s (%d bars)\n", s.Symbol, s.File, s.Cache, s.Bars)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed", failed, len(res))
+	}
+
+	return nil
+}
+
+func synthCmd(_ context.Context, a *app, fs *flag.FlagSet, args []string) error {
+	var cf configFlags
+	fs.StringVar(&cf.path, "config", os.Getenv("CONFIG"), "config file (defaults to $CONFIG)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	cfg, err := cf.load()
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.ValidateSynthetic(); err != nil {
+		return err
+	}
+
+	if err := synth.Generate(*cfg.Synthetic); err != nil {
+		return err
+	}
+
+	for _, symbol := range slices.Sorted(maps.Keys(cfg.Synthetic.Series)) {
+		fmt.Fprintf(a.stdout, "%s: %d bars written to %s\n", symbol, cfg.Synthetic.Bars, cfg.Synthetic.Series[symbol].Path)
+	}
+
+	return nil
+}
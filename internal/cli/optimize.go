@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/batch"
+	"github.com/gamma-omg/trading-bot/internal/fsutil"
+	"github.com/gamma-omg/trading-bot/internal/optimize"
+)
+
+type paramFlags []optimize.Param
+
+func (p *paramFlags) String() string {
+	paths := make([]string, len(*p))
+	for i, param := range *p {
+		paths[i] = param.Path
+	}
+	return strings.Join(paths, ",")
+}
+
+func (p *paramFlags) Set(s string) error {
+	param, err := optimize.ParseParam(s)
+	if err != nil {
+		return err
+	}
+
+	*p = append(*p, param)
+	return nil
+}
+
+func optimizeCmd(ctx context.Context, a *app, fs *flag.FlagSet, args []string) error {
+	var params paramFlags
+	configPath := fs.String("config", os.Getenv("CONFIG"), "base config file (defaults to $CONFIG)")
+	fs.Var(&params, "param", "parameter range as path=min:max:step or path=v1,v2 (repeatable)")
+	metric := fs.String("metric", "sharpe", "metric to rank results by")
+	order := fs.String("order", optimize.OrderDesc, "ranking order: desc or asc")
+	mode := fs.String("mode", optimize.ModeGrid, "search mode: grid or random")
+	samples := fs.Int("samples", 100, "number of samples in random mode")
+	seed := fs.Uint64("seed", 1, "random seed")
+	workers := fs.Int("workers", 0, "number of parallel backtests (defaults to the number of CPUs)")
+	out := fs.String("out", "", "results file (defaults to optimize.csv, or walkforward.json in walk-forward mode)")
+	dir := fs.String("dir", "optimize", "directory for per-run reports")
+	inSample := fs.Duration("in-sample", 0, "walk-forward in-sample window; enables walk-forward mode")
+	outOfSample := fs.Duration("out-of-sample", 0, "walk-forward out-of-sample window")
+	step := fs.Duration("step", 0, "walk-forward window step (defaults to the out-of-sample window)")
+	verbose := fs.Bool("v", false, "log backtest progress")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if *configPath == "" {
+		return usageError("no config file: pass -config or set CONFIG")
+	}
+
+	base, err := os.ReadFile(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	o, err := optimize.NewOptimizer(a.logger(*verbose), base, optimize.Options{
+		Params:  params,
+		Metric:  *metric,
+		Order:   *order,
+		Mode:    *mode,
+		Samples: *samples,
+		Seed:    *seed,
+		Workers: *workers,
+		Dir:     *dir,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *inSample > 0 {
+		return walkForward(ctx, a, o, optimize.WalkForwardOptions{InSample: *inSample, OutOfSample: *outOfSample, Step: *step}, *out)
+	}
+
+	if *out == "" {
+		*out = "optimize.csv"
+	}
+
+	results, err := o.Run(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = fsutil.WriteFile(*out, func(w io.Writer) error {
+		return o.WriteCsv(w, results)
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(results) > 0 && results[0].Err == nil {
+		fmt.Fprintf(a.stdout, "best run %d: %s\n", results[0].Run, strings.Join(results[0].Values, ", "))
+	}
+
+	return nil
+}
+
+func walkForward(ctx context.Context, a *app, o *optimize.Optimizer, opts optimize.WalkForwardOptions, out string) error {
+	if out == "" {
+		out = "walkforward.json"
+	}
+
+	r, err := o.WalkForward(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	err = fsutil.WriteFile(out, func(w io.Writer) error {
+		return optimize.WriteWalkForward(w, r)
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, w := range r.WalkForward.Windows {
+		fmt.Fprintf(a.stdout, "window %d %s..%s: %s %s\n", i+1, w.OutStart.Format(time.DateTime), w.OutEnd.Format(time.DateTime), strings.Join(w.Values, ", "), w.Error)
+	}
+
+	return nil
+}
+
+func batchCmd(ctx context.Context, a *app, fs *flag.FlagSet, args []string) error {
+	dir := fs.String("dir", "batch", "output directory; each run writes to its own subdirectory")
+	workers := fs.Int("workers", 0, "number of parallel backtests (defaults to the number of CPUs)")
+	summary := fs.String("summary", "", "summary csv file (defaults to summary.csv in -dir)")
+	verbose := fs.Bool("v", false, "log backtest progress")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return usageError("expected at least one config")
+	}
+
+	jobs, err := batch.LoadJobs(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	results, runErr := batch.NewBatch(a.logger(*verbose), *dir, *workers).Run(ctx, jobs)
+
+	if *summary == "" {
+		*summary = filepath.Join(*dir, "summary.csv")
+	}
+	if err := os.MkdirAll(filepath.Dir(*summary), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create summary directory: %w", err)
+	}
+
+	err = fsutil.WriteFile(*summary, func(w io.Writer) error {
+		return batch.WriteSummaryCsv(w, results)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := batch.WriteSummaryTable(a.stdout, results); err != nil {
+		return err
+	}
+
+	return runErr
+}
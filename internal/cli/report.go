@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/gamma-omg/trading-bot/internal/agent"
+	"github.com/gamma-omg/trading-bot/internal/fsutil"
+)
+
+func reportCmd(_ context.Context, a *app, fs *flag.FlagSet, args []string) error {
+	format := fs.String("format", "table", "output format: table, json or html")
+	out := fs.String("o", "", "output file (defaults to stdout)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return usageError("expected exactly one report")
+	}
+
+	var write func(io.Writer, agent.JsonReport) error
+	switch *format {
+	case "table":
+		write = agent.WriteReportTable
+	case "json":
+		write = writeJson[agent.JsonReport]
+	case "html":
+		write = agent.WriteHtmlReport
+	default:
+		return usageError(fmt.Sprintf("unknown format: %s", *format))
+	}
+
+	report, err := agent.ReadJsonReport(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		return write(a.stdout, report)
+	}
+
+	return fsutil.WriteFile(*out, func(w io.Writer) error {
+		return write(w, report)
+	})
+}
+
+func diffCmd(_ context.Context, a *app, fs *flag.FlagSet, args []string) error {
+	format := fs.String("format", "table", "output format: table or json")
+	fail := fs.Bool("fail", false, "exit with status 1 when the reports differ")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 2 {
+		return usageError("expected a base report and at least one other report")
+	}
+	if *format != "table" && *format != "json" {
+		return usageError(fmt.Sprintf("unknown format: %s", *format))
+	}
+
+	basePath := fs.Arg(0)
+	base, err := agent.ReadJsonReport(basePath)
+	if err != nil {
+		return err
+	}
+
+	equal := true
+	var diffs []agent.ReportDiff
+	for _, path := range fs.Args()[1:] {
+		other, err := agent.ReadJsonReport(path)
+		if err != nil {
+			return err
+		}
+
+		d := agent.DiffReports(basePath, base, path, other)
+		equal = equal && d.Equal()
+		diffs = append(diffs, d)
+	}
+
+	switch *format {
+	case "json":
+		if err := writeJson(a.stdout, diffs); err != nil {
+			return err
+		}
+	default:
+		for i, d := range diffs {
+			if i > 0 {
+				fmt.Fprintln(a.stdout)
+			}
+			if err := agent.WriteDiffTable(a.stdout, d); err != nil {
+				return err
+			}
+		}
+	}
+
+	if *fail && !equal {
+		return exitCode(1)
+	}
+
+	return nil
+}
+
+func monteCarloCmd(_ context.Context, a *app, fs *flag.FlagSet, args []string) error {
+	method := fs.String("method", agent.MonteCarloBootstrap, "resampling method: bootstrap, shuffle or skip")
+	runs := fs.Int("runs", 1000, "number of simulated equity paths")
+	seed := fs.Uint64("seed", 1, "random seed")
+	capital := fs.Float64("capital", 0, "starting capital, usually the sum of the strategy budgets (required)")
+	skip := fs.Float64("skip", 0.1, "probability of skipping a trade in skip mode")
+	ruin := fs.Float64("ruin", 0.5, "fraction of capital lost that counts as ruin")
+	format := fs.String("format", "table", "output format: table or json")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return usageError("expected exactly one report")
+	}
+	if *capital <= 0 {
+		return usageError("-capital is required")
+	}
+	if *format != "table" && *format != "json" {
+		return usageError(fmt.Sprintf("unknown format: %s", *format))
+	}
+
+	report, err := agent.ReadJsonReport(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	opts := agent.MonteCarloOptions{
+		Method:        *method,
+		Runs:          *runs,
+		Seed:          *seed,
+		Capital:       *capital,
+		RuinThreshold: *ruin,
+	}
+	if *method == agent.MonteCarloSkip {
+		opts.SkipProb = *skip
+	}
+
+	mc, err := agent.MonteCarlo(report, opts)
+	if err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		return writeJson(a.stdout, mc)
+	}
+
+	return agent.WriteMonteCarloTable(a.stdout, mc)
+}
+
+func writeJson[T any](w io.Writer, v T) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	if err := e.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode json: %w", err)
+	}
+
+	return nil
+}
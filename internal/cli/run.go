@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+
+	"github.com/gamma-omg/trading-bot/internal/agent"
+	"github.com/gamma-omg/trading-bot/internal/config"
+)
+
+func runCmd(ctx context.Context, a *app, fs *flag.FlagSet, args []string) error {
+	var cf configFlags
+	cf.register(fs)
+	quiet := fs.Bool("q", false, "only log warnings and errors")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	cfg, err := cf.load()
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	_, err = runAgent(ctx, a.logger(!*quiet), *cfg)
+	return err
+}
+
+func backtestCmd(ctx context.Context, a *app, fs *flag.FlagSet, args []string) error {
+	var cf configFlags
+	cf.register(fs)
+	quiet := fs.Bool("q", false, "only log warnings and errors")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	cfg, err := cf.load()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := cfg.PlatformRef.Platform.(config.Emulator); !ok {
+		return errors.New("backtest requires the emulator platform")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	report, err := runAgent(ctx, a.logger(!*quiet), *cfg)
+	if err != nil {
+		return err
+	}
+
+	if report != nil {
+		return agent.WriteReportTable(a.stdout, report.Report())
+	}
+
+	return nil
+}
+
+type reportSource interface {
+	Report() agent.JsonReport
+}
+
+func runAgent(ctx context.Context, log *slog.Logger, cfg config.Config) (reportSource, error) {
+	r, err := agent.NewReportBuilder(log, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create report builder: %w", err)
+	}
+
+	a, err := agent.NewTradingAgent(log, cfg, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trading agent: %w", err)
+	}
+
+	if err := a.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		return nil, err
+	}
+
+	report, _ := r.(reportSource)
+	return report, nil
+}
+
+func validateCmd(_ context.Context, a *app, fs *flag.FlagSet, args []string) error {
+	var cf configFlags
+	cf.register(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if cf.path == "" {
+		return usageError("no config file: pass -config or set CONFIG")
+	}
+
+	cfg, err := config.ReadFromFile(cf.path)
+	if err != nil {
+		fmt.Fprintln(a.stdout, err)
+		return exitCode(1)
+	}
+
+	var problems []string
+	if err := cf.apply(cfg); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	validate := cfg.Validate
+	if cfg.SyntheticOnly() {
+		validate = cfg.ValidateSynthetic
+	}
+
+	var invalid config.ValidationError
+	if err := validate(); errors.As(err, &invalid) {
+		for _, v := range invalid {
+			problems = append(problems, v.Error())
+		}
+	}
+
+	for _, p := range problems {
+		fmt.Fprintln(a.stdout, p)
+	}
+
+	if len(problems) > 0 {
+		noun := "problems"
+		if len(problems) == 1 {
+			noun = "problem"
+		}
+		fmt.Fprintf(a.stdout, "%d %s found in %s\n", len(problems), noun, cf.path)
+		return exitCode(1)
+	}
+
+	fmt.Fprintf(a.stdout, "%s is valid\n", cf.path)
+	return nil
+}
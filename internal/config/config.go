@@ -5,24 +5,97 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Strategies  map[string]Strategy `yaml:"strategies"`
-	Report      string              `yaml:"report"`
-	PlatformRef PlatformReference   `yaml:"platform"`
+	Strategies   map[string]Strategy `yaml:"strategies"`
+	Report       string              `yaml:"report"`
+	ReportFormat string              `yaml:"report_format"`
+	Journal      string              `yaml:"journal"`
+	Metrics      Metrics             `yaml:"metrics"`
+	Equity       *EquityCurve        `yaml:"equity"`
+	Benchmark    Benchmark           `yaml:"benchmark"`
+	Synthetic    *Synthetic          `yaml:"synthetic"`
+	PlatformRef  PlatformReference   `yaml:"platform"`
+
+	source *yaml.Node
+}
+
+type Metrics struct {
+	ReturnPeriod time.Duration `yaml:"return_period"`
+	RiskFreeRate float64       `yaml:"risk_free_rate"`
+}
+
+type Benchmark struct {
+	Symbol string `yaml:"symbol"`
+}
+
+type EquityCurve struct {
+	Interval time.Duration `yaml:"interval"`
+}
+
+type Synthetic struct {
+	Seed     uint64                     `yaml:"seed"`
+	Start    time.Time                  `yaml:"start"`
+	Interval time.Duration              `yaml:"interval"`
+	Bars     int                        `yaml:"bars"`
+	Series   map[string]SyntheticSeries `yaml:"series"`
+}
+
+// SyntheticSeries drift and volatility are annualized, regimes replace them while active.
+type SyntheticSeries struct {
+	Path       string           `yaml:"path"`
+	Price      float64          `yaml:"price"`
+	Drift      float64          `yaml:"drift"`
+	Volatility float64          `yaml:"volatility"`
+	Volume     float64          `yaml:"volume"`
+	Decimals   int              `yaml:"decimals"`
+	Garch      *Garch           `yaml:"garch"`
+	Jumps      *Jumps           `yaml:"jumps"`
+	Regimes    []Regime         `yaml:"regimes"`
+	Trends     []SyntheticTrend `yaml:"trends"`
+}
+
+type Garch struct {
+	Alpha float64 `yaml:"alpha"`
+	Beta  float64 `yaml:"beta"`
+}
+
+type Jumps struct {
+	Intensity float64 `yaml:"intensity"`
+	Mean      float64 `yaml:"mean"`
+	StdDev    float64 `yaml:"std_dev"`
+}
+
+type Regime struct {
+	Name       string        `yaml:"name"`
+	Drift      float64       `yaml:"drift"`
+	Volatility float64       `yaml:"volatility"`
+	Duration   time.Duration `yaml:"duration"`
+}
+
+type SyntheticTrend struct {
+	Start time.Time `yaml:"start"`
+	End   time.Time `yaml:"end"`
+	Drift float64   `yaml:"drift"`
 }
 
 func Read(r io.Reader) (*Config, error) {
+	var node yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&node); err != nil {
+		return nil, fmt.Errorf("unable to parse config file: %w", err)
+	}
+
 	var cfg Config
-	d := yaml.NewDecoder(r)
-	err := d.Decode(&cfg)
-	if err != nil {
+	if err := node.Decode(&cfg); err != nil {
 		return nil, fmt.Errorf("unable to parse config file: %w", err)
 	}
+	cfg.source = &node
 
 	return &cfg, nil
 }
@@ -47,12 +120,51 @@ type Strategy struct {
 	IndRef         IndicatorReference `yaml:"indicator"`
 	Prefetch       int                `yaml:"prefetch"`
 	AggregateBars  int                `yaml:"aggregate_bars"`
+	Bars           *BarType           `yaml:"bars"`
+	Pipeline       BarPipeline        `yaml:"pipeline"`
 	DataDump       string             `yaml:"data_dump"`
 	DebugLevel     DebugLevel         `yaml:"debug_level"`
 	DebugDir       string             `yaml:"debug_dir"`
 	DebugWindow    int                `yaml:"debug_window"`
 }
 
+// BarDuration is the duration of a strategy bar, zero for bars that are not time based.
+func (s Strategy) BarDuration() time.Duration {
+	if s.Bars != nil {
+		if s.Bars.Type == "time" {
+			return s.Bars.Interval
+		}
+		return 0
+	}
+
+	return time.Duration(max(1, s.AggregateBars)) * time.Minute
+}
+
+// WarmupBars is the number of one-minute bars the indicator needs before it produces signals.
+func (s Strategy) WarmupBars() int {
+	return prefetchLookback(s.IndRef, s.BarDuration())
+}
+
+type BarType struct {
+	Type     string        `yaml:"type"`
+	Interval time.Duration `yaml:"interval"`
+	Size     float64       `yaml:"size"`
+}
+
+type BarPipeline struct {
+	Dedupe      bool          `yaml:"dedupe"`
+	DropInvalid bool          `yaml:"drop_invalid"`
+	FillGaps    time.Duration `yaml:"fill_gaps"`
+	MaxFill     time.Duration `yaml:"max_fill"`
+	Outliers    *Outliers     `yaml:"outliers"`
+}
+
+type Outliers struct {
+	MaxReturn float64 `yaml:"max_return"`
+	Action    string  `yaml:"action"`
+	Reanchor  int     `yaml:"reanchor"`
+}
+
 type PlatformReference struct {
 	Platform Platform
 }
@@ -70,20 +182,28 @@ const (
 )
 
 type MACD struct {
-	Fast          int     `yaml:"fast"`
-	Slow          int     `yaml:"slow"`
-	Signal        int     `yaml:"signal"`
-	BuyThreshold  float64 `yaml:"buy_threshold"`
-	BuyCap        float64 `yaml:"buy_cap"`
-	SellThreshold float64 `yaml:"sell_threshold"`
-	SellCap       float64 `yaml:"sell_cap"`
-	CrossLookback int     `yaml:"cross_lookback"`
-	EmaWarmup     int     `yaml:"ema_warmup"`
+	Fast          int           `yaml:"fast"`
+	Slow          int           `yaml:"slow"`
+	Signal        int           `yaml:"signal"`
+	BuyThreshold  float64       `yaml:"buy_threshold"`
+	BuyCap        float64       `yaml:"buy_cap"`
+	SellThreshold float64       `yaml:"sell_threshold"`
+	SellCap       float64       `yaml:"sell_cap"`
+	CrossLookback int           `yaml:"cross_lookback"`
+	EmaWarmup     int           `yaml:"ema_warmup"`
+	Transform     string        `yaml:"transform"`
+	Timeframe     time.Duration `yaml:"timeframe"`
+}
+
+func (m MACD) Lookback() int {
+	return m.EmaWarmup * max(m.Fast, m.Slow, m.Signal)
 }
 
 type RSI struct {
-	Period     int     `yaml:"period"`
-	Overbought float64 `yaml:"overbought"`
+	Period     int           `yaml:"period"`
+	Overbought float64       `yaml:"overbought"`
+	Transform  string        `yaml:"transform"`
+	Timeframe  time.Duration `yaml:"timeframe"`
 }
 
 type Ensemble []struct {
@@ -97,6 +217,24 @@ type IndicatorReference struct {
 	Indicator Indicator
 }
 
+// Lookback is the number of bars the indicator tree needs before it produces a signal.
+func (w IndicatorReference) Lookback() int {
+	switch ind := w.Indicator.(type) {
+	case MACD:
+		return ind.Lookback()
+	case RSI:
+		return ind.Period
+	case Ensemble:
+		n := 0
+		for _, c := range ind {
+			n = max(n, c.IndRef.Lookback())
+		}
+		return n
+	default:
+		return 0
+	}
+}
+
 func (w *IndicatorReference) UnmarshalYAML(value *yaml.Node) error {
 	if len(value.Content) == 0 {
 		return nil
@@ -136,16 +274,111 @@ func (w *IndicatorReference) UnmarshalYAML(value *yaml.Node) error {
 // platform configs
 
 type Emulator struct {
-	Data           map[string]string `yaml:"data"`
-	Start          time.Time         `yaml:"start"`
-	End            time.Time         `yaml:"end"`
-	BuyCommission  float64           `yaml:"buy_commission"`
-	SellCommission float64           `yaml:"sell_commission"`
-	Balance        float64           `yaml:"balance"`
+	Data           map[string]string    `yaml:"data"`
+	Start          time.Time            `yaml:"start"`
+	End            time.Time            `yaml:"end"`
+	BuyCommission  float64              `yaml:"buy_commission"`
+	SellCommission float64              `yaml:"sell_commission"`
+	Balance        float64              `yaml:"balance"`
+	ClosePositions bool                 `yaml:"close_positions"`
+	Sync           bool                 `yaml:"sync"`
+	Replay         *Replay              `yaml:"replay"`
+	Schema         CsvSchema            `yaml:"schema"`
+	Schemas        map[string]CsvSchema `yaml:"schemas"`
+	Overlap        string               `yaml:"overlap"`
+	CacheDir       string               `yaml:"cache_dir"`
+	Quality        DataQuality          `yaml:"quality"`
+}
+
+type DataQuality struct {
+	Strict    bool          `yaml:"strict"`
+	Interval  time.Duration `yaml:"interval"`
+	MaxReturn float64       `yaml:"max_return"`
+}
+
+// SchemaFor returns the default schema with the fields set in the symbol schema overridden.
+func (e Emulator) SchemaFor(symbol string) CsvSchema {
+	s := e.Schema
+	o, ok := e.Schemas[symbol]
+	if !ok {
+		return s
+	}
+
+	override := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+
+	override(&s.Delimiter, o.Delimiter)
+	// time_unit and time_layout are alternatives, overriding one drops the other
+	if o.TimeUnit != "" || o.TimeLayout != "" {
+		s.TimeUnit, s.TimeLayout = o.TimeUnit, o.TimeLayout
+	}
+	override(&s.Timezone, o.Timezone)
+	override(&s.Columns.Time, o.Columns.Time)
+	override(&s.Columns.Open, o.Columns.Open)
+	override(&s.Columns.High, o.Columns.High)
+	override(&s.Columns.Low, o.Columns.Low)
+	override(&s.Columns.Close, o.Columns.Close)
+	override(&s.Columns.Volume, o.Columns.Volume)
+	if o.Header != nil {
+		s.Header = o.Header
+	}
+
+	return s
+}
+
+type CsvSchema struct {
+	Delimiter  string     `yaml:"delimiter"`
+	Header     *bool      `yaml:"header"`
+	Columns    CsvColumns `yaml:"columns"`
+	TimeUnit   string     `yaml:"time_unit"`
+	TimeLayout string     `yaml:"time_layout"`
+	Timezone   string     `yaml:"timezone"`
+}
+
+// CsvColumns holds either header names or zero based indexes of the bar fields.
+type CsvColumns struct {
+	Time   string `yaml:"time"`
+	Open   string `yaml:"open"`
+	High   string `yaml:"high"`
+	Low    string `yaml:"low"`
+	Close  string `yaml:"close"`
+	Volume string `yaml:"volume"`
+}
+
+type Replay struct {
+	Speed    ReplaySpeed `yaml:"speed"`
+	Paused   bool        `yaml:"paused"`
+	Controls bool        `yaml:"controls"`
+}
+
+// ReplaySpeed is a multiplier of the real bar pace, zero means as fast as possible.
+type ReplaySpeed float64
+
+func (s *ReplaySpeed) UnmarshalYAML(value *yaml.Node) error {
+	v := strings.ToLower(strings.TrimSpace(value.Value))
+	if v == "" || v == "max" {
+		*s = 0
+		return nil
+	}
+
+	f, err := strconv.ParseFloat(strings.TrimSuffix(v, "x"), 64)
+	if err != nil {
+		return fmt.Errorf("invalid replay speed %q: %w", value.Value, err)
+	}
+	if f < 0 {
+		return fmt.Errorf("replay speed cannot be negative: %s", value.Value)
+	}
+
+	*s = ReplaySpeed(f)
+	return nil
 }
 
 type Alpaca struct {
 	BaseUrl string `yaml:"base_url"`
+	DataUrl string `yaml:"data_url"`
 	ApiKey  string `yaml:"api_key"`
 	Secret  string `yaml:"secret"`
 }
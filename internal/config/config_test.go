@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -28,6 +29,18 @@ strategies:
         sell_threshold: -5.5
         sell_cap: -200.4
         cross_lookback: 3
+        transform: heikin_ashi
+        timeframe: 1h
+    bars:
+      type: dollar
+      size: 250000
+    pipeline:
+      dedupe: true
+      fill_gaps: 1m
+      max_fill: 2h
+      outliers:
+        max_return: 0.2
+        action: clip
 `))
 
 	require.NoError(t, err)
@@ -40,6 +53,13 @@ strategies:
 	assert.Equal(t, 0.7, btc.SellConfidence)
 	assert.Equal(t, 1.0, btc.PositionScale)
 	assert.Equal(t, 1024, btc.MarketBuffer)
+	assert.Equal(t, &BarType{Type: "dollar", Size: 250000}, btc.Bars)
+	assert.Equal(t, BarPipeline{
+		Dedupe:   true,
+		FillGaps: time.Minute,
+		MaxFill:  2 * time.Hour,
+		Outliers: &Outliers{MaxReturn: 0.2, Action: "clip"},
+	}, btc.Pipeline)
 
 	macd, ok := btc.IndRef.Indicator.(MACD)
 	require.True(t, ok)
@@ -52,6 +72,83 @@ strategies:
 	assert.Equal(t, 100.9, macd.BuyCap)
 	assert.Equal(t, -200.4, macd.SellCap)
 	assert.Equal(t, 3, macd.CrossLookback)
+	assert.Equal(t, "heikin_ashi", macd.Transform)
+	assert.Equal(t, time.Hour, macd.Timeframe)
+}
+
+func TestRead_Metrics(t *testing.T) {
+	cfg, err := Read(strings.NewReader(`
+metrics:
+  return_period: 1h
+  risk_free_rate: 0.04
+`))
+
+	require.NoError(t, err)
+	assert.Equal(t, Metrics{ReturnPeriod: time.Hour, RiskFreeRate: 0.04}, cfg.Metrics)
+}
+
+func TestRead_EquityAndBenchmark(t *testing.T) {
+	cfg, err := Read(strings.NewReader(`
+equity:
+  interval: 4h
+benchmark:
+  symbol: SPY
+`))
+
+	require.NoError(t, err)
+	assert.Equal(t, "SPY", cfg.Benchmark.Symbol)
+	require.NotNil(t, cfg.Equity)
+	assert.Equal(t, 4*time.Hour, cfg.Equity.Interval)
+}
+
+func TestRead_Synthetic(t *testing.T) {
+	cfg, err := Read(strings.NewReader(`
+synthetic:
+  seed: 42
+  start: 2025-01-01T00:00:00Z
+  interval: 1m
+  bars: 1000
+  series:
+    BTC:
+      path: data/btc.csv
+      price: 50000
+      drift: 0.1
+      volatility: 0.6
+      volume: 5
+      decimals: 2
+      garch:
+        alpha: 0.1
+        beta: 0.85
+      jumps:
+        intensity: 12
+        mean: -0.02
+        std_dev: 0.05
+      regimes:
+        - name: calm
+          volatility: 0.3
+          duration: 72h
+      trends:
+        - start: 2025-01-01T06:00:00Z
+          end: 2025-01-01T12:00:00Z
+          drift: 5
+`))
+
+	require.NoError(t, err)
+	require.NotNil(t, cfg.Synthetic)
+	assert.Equal(t, uint64(42), cfg.Synthetic.Seed)
+	assert.Equal(t, time.Minute, cfg.Synthetic.Interval)
+	assert.Equal(t, 1000, cfg.Synthetic.Bars)
+
+	s := cfg.Synthetic.Series["BTC"]
+	assert.Equal(t, "data/btc.csv", s.Path)
+	assert.Equal(t, 50000.0, s.Price)
+	assert.Equal(t, 2, s.Decimals)
+	assert.Equal(t, &Garch{Alpha: 0.1, Beta: 0.85}, s.Garch)
+	assert.Equal(t, &Jumps{Intensity: 12, Mean: -0.02, StdDev: 0.05}, s.Jumps)
+	assert.Equal(t, []Regime{{Name: "calm", Volatility: 0.3, Duration: 72 * time.Hour}}, s.Regimes)
+	require.Len(t, s.Trends, 1)
+	assert.Equal(t, 5.0, s.Trends[0].Drift)
+	assert.Equal(t, 6*time.Hour, s.Trends[0].End.Sub(s.Trends[0].Start))
 }
 
 func TestRead_Emulator(t *testing.T) {
@@ -65,6 +162,14 @@ platform:
     end: 2020-12-31T08:30:12.000Z
     buy_commission: 0.002
     sell_commission: 0.0015
+    close_positions: true
+    sync: true
+    overlap: reject
+    cache_dir: /var/cache/bars
+    quality:
+      strict: true
+      interval: 1m
+      max_return: 0.1
 `))
 
 	require.NoError(t, err)
@@ -83,6 +188,11 @@ platform:
 	assert.Equal(t, end, emu.End)
 	assert.Equal(t, 0.002, emu.BuyCommission)
 	assert.Equal(t, 0.0015, emu.SellCommission)
+	assert.True(t, emu.ClosePositions)
+	assert.True(t, emu.Sync)
+	assert.Equal(t, "reject", emu.Overlap)
+	assert.Equal(t, "/var/cache/bars", emu.CacheDir)
+	assert.Equal(t, DataQuality{Strict: true, Interval: time.Minute, MaxReturn: 0.1}, emu.Quality)
 }
 
 func TestRead_Ensemble(t *testing.T) {
@@ -118,3 +228,129 @@ strategies:
 	_, ok = ensemble[1].IndRef.Indicator.(MACD)
 	assert.True(t, ok)
 }
+
+func TestRead_Replay(t *testing.T) {
+	tbl := []struct {
+		speed    string
+		expected ReplaySpeed
+		err      bool
+	}{
+		{speed: "1", expected: 1},
+		{speed: "60x", expected: 60},
+		{speed: "0.5X", expected: 0.5},
+		{speed: "max", expected: 0},
+		{speed: "fast", err: true},
+		{speed: "-2", err: true},
+	}
+
+	for i, c := range tbl {
+		t.Run(fmt.Sprintf("case_%d", i), func(t *testing.T) {
+			cfg, err := Read(strings.NewReader(fmt.Sprintf(`
+platform:
+  emulator:
+    replay:
+      speed: %s
+      paused: true
+`, c.speed)))
+
+			if c.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			emu, ok := cfg.PlatformRef.Platform.(Emulator)
+			require.True(t, ok)
+			require.NotNil(t, emu.Replay)
+			assert.Equal(t, c.expected, emu.Replay.Speed)
+			assert.True(t, emu.Replay.Paused)
+		})
+	}
+}
+
+func TestRead_EmulatorSchema(t *testing.T) {
+	cfg, err := Read(strings.NewReader(`
+platform:
+  emulator:
+    data:
+      BTC: /var/data/btc.csv
+      ETH: /var/data/eth.csv
+    schema:
+      time_unit: ms
+    schemas:
+      ETH:
+        delimiter: ";"
+        header: false
+        time_layout: "2006-01-02 15:04:05"
+        timezone: Europe/Berlin
+        columns:
+          time: 0
+          close: 4
+`))
+
+	require.NoError(t, err)
+
+	emu, ok := cfg.PlatformRef.Platform.(Emulator)
+	require.True(t, ok)
+
+	btc := emu.SchemaFor("BTC")
+	assert.Equal(t, "ms", btc.TimeUnit)
+	assert.Nil(t, btc.Header)
+
+	eth := emu.SchemaFor("ETH")
+	assert.Equal(t, ";", eth.Delimiter)
+	require.NotNil(t, eth.Header)
+	assert.False(t, *eth.Header)
+	assert.Equal(t, "2006-01-02 15:04:05", eth.TimeLayout)
+	assert.Equal(t, "Europe/Berlin", eth.Timezone)
+	assert.Equal(t, "0", eth.Columns.Time)
+	assert.Equal(t, "4", eth.Columns.Close)
+	assert.Empty(t, eth.TimeUnit)
+}
+
+func TestEmulator_SchemaFor(t *testing.T) {
+	header := true
+	noHeader := false
+	emu := Emulator{
+		Schema: CsvSchema{
+			Delimiter: ";",
+			Header:    &header,
+			TimeUnit:  "ms",
+			Timezone:  "UTC",
+			Columns:   CsvColumns{Time: "ts", Open: "o", High: "h", Low: "l", Close: "c", Volume: "v"},
+		},
+		Schemas: map[string]CsvSchema{
+			"ETH": {Delimiter: "|", Columns: CsvColumns{Close: "last"}},
+			"SOL": {Header: &noHeader, TimeUnit: "s"},
+			"XRP": {TimeLayout: "2006-01-02 15:04:05"},
+		},
+	}
+
+	assert.Equal(t, emu.Schema, emu.SchemaFor("BTC"))
+
+	assert.Equal(t, CsvSchema{
+		Delimiter: "|",
+		Header:    &header,
+		TimeUnit:  "ms",
+		Timezone:  "UTC",
+		Columns:   CsvColumns{Time: "ts", Open: "o", High: "h", Low: "l", Close: "last", Volume: "v"},
+	}, emu.SchemaFor("ETH"))
+
+	sol := emu.SchemaFor("SOL")
+	require.NotNil(t, sol.Header)
+	assert.False(t, *sol.Header)
+	assert.Equal(t, "s", sol.TimeUnit)
+	assert.Equal(t, ";", sol.Delimiter)
+	assert.Equal(t, emu.Schema.Columns, sol.Columns)
+	assert.True(t, *emu.Schema.Header)
+
+	xrp := emu.SchemaFor("XRP")
+	assert.Equal(t, "2006-01-02 15:04:05", xrp.TimeLayout)
+	assert.Empty(t, xrp.TimeUnit)
+
+	emu.Schema.TimeUnit = ""
+	emu.Schema.TimeLayout = "2006-01-02"
+	sol = emu.SchemaFor("SOL")
+	assert.Equal(t, "s", sol.TimeUnit)
+	assert.Empty(t, sol.TimeLayout)
+}
@@ -0,0 +1,487 @@
+package config
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"math"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Violation struct {
+	Path    string
+	Line    int
+	Message string
+}
+
+func (v Violation) Error() string {
+	if v.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", v.Line, v.Path, v.Message)
+	}
+
+	return fmt.Sprintf("%s: %s", v.Path, v.Message)
+}
+
+type ValidationError []Violation
+
+func (e ValidationError) Error() string {
+	lines := make([]string, len(e))
+	for i, v := range e {
+		lines[i] = v.Error()
+	}
+
+	return fmt.Sprintf("invalid config:\n%s", strings.Join(lines, "\n"))
+}
+
+// Validate checks the values of a config, including that emulator data paths match files. Violations are
+// sorted by line for configs created by Read.
+func (c *Config) Validate() error {
+	return c.validate(func(v *validator) {
+		v.config(c)
+	})
+}
+
+// ValidateSynthetic checks only the synthetic section, for generator-only configs of the synth command.
+func (c *Config) ValidateSynthetic() error {
+	return c.validate(func(v *validator) {
+		if c.Synthetic == nil {
+			v.errorf(yamlPath{"synthetic"}, "a synthetic section is required")
+			return
+		}
+		v.synthetic(yamlPath{"synthetic"}, *c.Synthetic)
+	})
+}
+
+// SyntheticOnly reports whether the config only has a synthetic section.
+func (c *Config) SyntheticOnly() bool {
+	return c.Synthetic != nil && len(c.Strategies) == 0 && c.PlatformRef.Platform == nil
+}
+
+func (c *Config) validate(check func(v *validator)) error {
+	v := validator{root: c.source}
+	check(&v)
+	if len(v.violations) == 0 {
+		return nil
+	}
+
+	slices.SortStableFunc(v.violations, func(a, b Violation) int {
+		return cmp.Compare(a.Line, b.Line)
+	})
+	return ValidationError(v.violations)
+}
+
+type yamlPath []string
+
+func (p yamlPath) key(k string) yamlPath {
+	return append(p[:len(p):len(p)], k)
+}
+
+func (p yamlPath) index(i int) yamlPath {
+	return p.key("[" + strconv.Itoa(i) + "]")
+}
+
+func (p yamlPath) String() string {
+	var b strings.Builder
+	for i, s := range p {
+		if i > 0 && !strings.HasPrefix(s, "[") {
+			b.WriteByte('.')
+		}
+		b.WriteString(s)
+	}
+
+	return b.String()
+}
+
+type validator struct {
+	root       *yaml.Node
+	violations []Violation
+}
+
+func (v *validator) errorf(p yamlPath, format string, args ...any) {
+	v.violations = append(v.violations, Violation{
+		Path:    p.String(),
+		Line:    v.line(p),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// line returns the line of the deepest node of the path that exists in the yaml source.
+func (v *validator) line(p yamlPath) int {
+	node := v.root
+	if node == nil {
+		return 0
+	}
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	line := 0
+	for _, seg := range p {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+
+		next, l := childNode(node, seg)
+		if next == nil {
+			break
+		}
+		node, line = next, l
+	}
+
+	return line
+}
+
+func childNode(node *yaml.Node, seg string) (*yaml.Node, int) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == seg {
+				return node.Content[i+1], node.Content[i].Line
+			}
+		}
+	case yaml.SequenceNode:
+		i, err := strconv.Atoi(strings.Trim(seg, "[]"))
+		if err == nil && i >= 0 && i < len(node.Content) {
+			return node.Content[i], node.Content[i].Line
+		}
+	}
+
+	return nil, 0
+}
+
+func (v *validator) config(c *Config) {
+	if len(c.Strategies) == 0 {
+		v.errorf(yamlPath{"strategies"}, "at least one strategy is required")
+	}
+	for _, symbol := range slices.Sorted(maps.Keys(c.Strategies)) {
+		v.strategy(yamlPath{"strategies", symbol}, c.Strategies[symbol])
+	}
+
+	switch c.ReportFormat {
+	case "", "json", "html":
+	default:
+		v.errorf(yamlPath{"report_format"}, "unknown report format %q, expected json or html", c.ReportFormat)
+	}
+
+	if c.Metrics.ReturnPeriod < 0 {
+		v.errorf(yamlPath{"metrics", "return_period"}, "cannot be negative, got %s", c.Metrics.ReturnPeriod)
+	}
+	if c.Equity != nil && c.Equity.Interval < 0 {
+		v.errorf(yamlPath{"equity", "interval"}, "cannot be negative, got %s", c.Equity.Interval)
+	}
+	if c.Synthetic != nil {
+		v.synthetic(yamlPath{"synthetic"}, *c.Synthetic)
+	}
+
+	switch p := c.PlatformRef.Platform.(type) {
+	case nil:
+		v.errorf(yamlPath{"platform"}, "a platform is required")
+	case Emulator:
+		v.emulator(yamlPath{"platform", "emulator"}, p, c)
+	case Alpaca:
+		v.alpaca(yamlPath{"platform", "alpaca"}, p)
+	}
+}
+
+func (v *validator) strategy(p yamlPath, s Strategy) {
+	if s.Budget <= 0 {
+		v.errorf(p.key("budget"), "must be positive, got %d", s.Budget)
+	}
+	if s.BuyConfidence < 0 {
+		v.errorf(p.key("buy_confidence"), "cannot be negative, got %g", s.BuyConfidence)
+	}
+	if s.SellConfidence < 0 {
+		v.errorf(p.key("sell_confidence"), "cannot be negative, got %g", s.SellConfidence)
+	}
+	if s.TakeProfit <= 1 {
+		v.errorf(p.key("take_profit"), "must be greater than 1, got %g", s.TakeProfit)
+	}
+	if s.StopLoss < 0 || s.StopLoss >= 1 {
+		v.errorf(p.key("stop_loss"), "must be at least 0 and less than 1, got %g", s.StopLoss)
+	}
+	if s.PositionScale <= 0 {
+		v.errorf(p.key("position_scale"), "must be positive, got %g", s.PositionScale)
+	}
+
+	barDuration := s.BarDuration()
+	v.bars(p, s)
+	v.pipeline(p.key("pipeline"), s.Pipeline)
+
+	if s.IndRef.Indicator == nil {
+		v.errorf(p.key("indicator"), "an indicator is required")
+	} else {
+		v.indicator(p.key("indicator"), s.IndRef, barDuration)
+	}
+
+	lookback := s.IndRef.Lookback()
+	if s.MarketBuffer <= 0 {
+		v.errorf(p.key("market_buffer"), "must be positive, got %d", s.MarketBuffer)
+	} else if s.MarketBuffer < lookback {
+		v.errorf(p.key("market_buffer"), "must hold the %d bars the indicator needs, got %d", lookback, s.MarketBuffer)
+	}
+
+	if s.Prefetch < 0 {
+		v.errorf(p.key("prefetch"), "cannot be negative, got %d", s.Prefetch)
+	} else if need := prefetchLookback(s.IndRef, barDuration); s.Prefetch > 0 && s.Prefetch < need {
+		v.errorf(p.key("prefetch"), "must cover the indicator lookback of %d one-minute bars, got %d", need, s.Prefetch)
+	}
+
+	if s.DebugLevel < DebugNone || s.DebugLevel > DebugAll {
+		v.errorf(p.key("debug_level"), "must be between %d and %d, got %d", DebugNone, DebugAll, s.DebugLevel)
+	}
+	if s.DebugWindow < 0 {
+		v.errorf(p.key("debug_window"), "cannot be negative, got %d", s.DebugWindow)
+	} else if s.MarketBuffer > 0 && s.DebugWindow > s.MarketBuffer {
+		v.errorf(p.key("debug_window"), "cannot exceed market_buffer (%d), got %d", s.MarketBuffer, s.DebugWindow)
+	}
+}
+
+func (v *validator) bars(p yamlPath, s Strategy) {
+	if s.AggregateBars < 0 {
+		v.errorf(p.key("aggregate_bars"), "cannot be negative, got %d", s.AggregateBars)
+	}
+
+	b := s.Bars
+	if b == nil {
+		return
+	}
+
+	if s.AggregateBars > 1 {
+		v.errorf(p.key("bars"), "cannot be combined with aggregate_bars")
+	}
+
+	switch b.Type {
+	case "time":
+		if b.Interval < time.Minute {
+			v.errorf(p.key("bars").key("interval"), "must be at least 1m for time bars, got %s", b.Interval)
+		}
+	case "tick":
+		if b.Size < 1 || b.Size != math.Trunc(b.Size) {
+			v.errorf(p.key("bars").key("size"), "must be a whole number of at least 1 for tick bars, got %g", b.Size)
+		}
+	case "volume", "dollar", "range", "renko":
+		if b.Size <= 0 {
+			v.errorf(p.key("bars").key("size"), "must be positive for %s bars, got %g", b.Type, b.Size)
+		}
+	default:
+		v.errorf(p.key("bars").key("type"), "unknown bars type %q", b.Type)
+	}
+}
+
+func (v *validator) pipeline(p yamlPath, b BarPipeline) {
+	if b.FillGaps < 0 {
+		v.errorf(p.key("fill_gaps"), "cannot be negative, got %s", b.FillGaps)
+	}
+	if b.MaxFill < 0 {
+		v.errorf(p.key("max_fill"), "cannot be negative, got %s", b.MaxFill)
+	}
+
+	if o := b.Outliers; o != nil {
+		if o.MaxReturn < 0 {
+			v.errorf(p.key("outliers").key("max_return"), "cannot be negative, got %g", o.MaxReturn)
+		}
+		if o.Reanchor < 0 {
+			v.errorf(p.key("outliers").key("reanchor"), "cannot be negative, got %d", o.Reanchor)
+		}
+		switch o.Action {
+		case "", "drop", "clip":
+		default:
+			v.errorf(p.key("outliers").key("action"), "unknown outlier action %q, expected drop or clip", o.Action)
+		}
+	}
+}
+
+func (v *validator) indicator(p yamlPath, ref IndicatorReference, barDuration time.Duration) {
+	switch ind := ref.Indicator.(type) {
+	case MACD:
+		v.macd(p.key("macd"), ind, barDuration)
+	case RSI:
+		v.rsi(p.key("rsi"), ind, barDuration)
+	case Ensemble:
+		v.ensemble(p.key("ensemble"), ind, barDuration)
+	}
+}
+
+func (v *validator) macd(p yamlPath, m MACD, barDuration time.Duration) {
+	for _, f := range []struct {
+		name  string
+		value int
+	}{{"fast", m.Fast}, {"slow", m.Slow}, {"signal", m.Signal}, {"ema_warmup", m.EmaWarmup}, {"cross_lookback", m.CrossLookback}} {
+		if f.value <= 0 {
+			v.errorf(p.key(f.name), "must be positive, got %d", f.value)
+		}
+	}
+
+	if m.Fast > 0 && m.Slow > 0 && m.Fast >= m.Slow {
+		v.errorf(p.key("fast"), "must be less than slow (%d), got %d", m.Slow, m.Fast)
+	}
+	if m.BuyCap == m.BuyThreshold {
+		v.errorf(p.key("buy_cap"), "must differ from buy_threshold (%g)", m.BuyThreshold)
+	}
+	if m.SellCap == m.SellThreshold {
+		v.errorf(p.key("sell_cap"), "must differ from sell_threshold (%g)", m.SellThreshold)
+	}
+
+	v.indicatorBars(p, m.Transform, m.Timeframe, barDuration)
+}
+
+func (v *validator) rsi(p yamlPath, r RSI, barDuration time.Duration) {
+	if r.Period < 2 {
+		v.errorf(p.key("period"), "must be at least 2, got %d", r.Period)
+	}
+	if r.Overbought < 0.5 || r.Overbought > 1 {
+		v.errorf(p.key("overbought"), "must be between 0.5 and 1, got %g", r.Overbought)
+	}
+
+	v.indicatorBars(p, r.Transform, r.Timeframe, barDuration)
+}
+
+func (v *validator) ensemble(p yamlPath, e Ensemble, barDuration time.Duration) {
+	if len(e) == 0 {
+		v.errorf(p, "must contain at least one indicator")
+		return
+	}
+
+	var total float64
+	for i, c := range e {
+		if c.Weight < 0 {
+			v.errorf(p.index(i).key("weight"), "cannot be negative, got %g", c.Weight)
+		}
+		total += c.Weight
+
+		if c.IndRef.Indicator == nil {
+			v.errorf(p.index(i).key("indicator"), "an indicator is required")
+			continue
+		}
+		v.indicator(p.index(i).key("indicator"), c.IndRef, barDuration)
+	}
+
+	if total <= 0 {
+		v.errorf(p, "weights must add up to a positive number")
+	}
+}
+
+func (v *validator) indicatorBars(p yamlPath, transform string, timeframe, barDuration time.Duration) {
+	switch transform {
+	case "", "raw", "heikin_ashi", "log", "typical":
+	default:
+		v.errorf(p.key("transform"), "unknown bar transform %q", transform)
+	}
+
+	if timeframe < 0 {
+		v.errorf(p.key("timeframe"), "cannot be negative, got %s", timeframe)
+	} else if timeframe > 0 && timeframe < barDuration {
+		v.errorf(p.key("timeframe"), "cannot be shorter than the strategy bars (%s), got %s", barDuration, timeframe)
+	}
+}
+
+// prefetchLookback converts the indicator lookback into the one-minute bars the platforms prefetch. Bars that are
+// not time based need at least one platform bar each.
+func prefetchLookback(ref IndicatorReference, barDuration time.Duration) int {
+	leaf := func(bars int, timeframe time.Duration) int {
+		d := max(timeframe, barDuration)
+		if d > time.Minute {
+			return bars * int(d/time.Minute)
+		}
+		return bars
+	}
+
+	switch ind := ref.Indicator.(type) {
+	case MACD:
+		return leaf(ind.Lookback(), ind.Timeframe)
+	case RSI:
+		return leaf(ind.Period, ind.Timeframe)
+	case Ensemble:
+		n := 0
+		for _, c := range ind {
+			n = max(n, prefetchLookback(c.IndRef, barDuration))
+		}
+		return n
+	default:
+		return 0
+	}
+}
+
+func (v *validator) emulator(p yamlPath, e Emulator, c *Config) {
+	if e.End.IsZero() {
+		v.errorf(p.key("end"), "an end time is required")
+	} else if !e.Start.Before(e.End) {
+		v.errorf(p.key("start"), "must be before end (%s), got %s", e.End.Format(time.RFC3339), e.Start.Format(time.RFC3339))
+	}
+
+	if e.Balance < 0 {
+		v.errorf(p.key("balance"), "cannot be negative, got %g", e.Balance)
+	}
+	if e.BuyCommission < 0 || e.BuyCommission >= 1 {
+		v.errorf(p.key("buy_commission"), "must be at least 0 and less than 1, got %g", e.BuyCommission)
+	}
+	if e.SellCommission < 0 || e.SellCommission >= 1 {
+		v.errorf(p.key("sell_commission"), "must be at least 0 and less than 1, got %g", e.SellCommission)
+	}
+
+	switch e.Overlap {
+	case "", "dedupe", "reject":
+	default:
+		v.errorf(p.key("overlap"), "unknown overlap policy %q, expected dedupe or reject", e.Overlap)
+	}
+
+	if e.Quality.Interval < 0 {
+		v.errorf(p.key("quality").key("interval"), "cannot be negative, got %s", e.Quality.Interval)
+	}
+	if e.Quality.MaxReturn < 0 {
+		v.errorf(p.key("quality").key("max_return"), "cannot be negative, got %g", e.Quality.MaxReturn)
+	}
+
+	for _, symbol := range slices.Sorted(maps.Keys(c.Strategies)) {
+		if _, ok := e.Data[symbol]; !ok {
+			v.errorf(p.key("data"), "no data for strategy %s", symbol)
+		}
+	}
+	if b := c.Benchmark.Symbol; b != "" {
+		if _, ok := e.Data[b]; !ok {
+			v.errorf(yamlPath{"benchmark", "symbol"}, "no emulator data for benchmark %s", b)
+		}
+	}
+
+	for _, symbol := range slices.Sorted(maps.Keys(e.Data)) {
+		path := e.Data[symbol]
+		if matches, err := filepath.Glob(path); err != nil || len(matches) == 0 {
+			v.errorf(p.key("data").key(symbol), "no files match %s", path)
+		}
+	}
+}
+
+func (v *validator) alpaca(p yamlPath, a Alpaca) {
+	if a.ApiKey == "" {
+		v.errorf(p.key("api_key"), "an api key is required")
+	}
+	if a.Secret == "" {
+		v.errorf(p.key("secret"), "a secret is required")
+	}
+}
+
+func (v *validator) synthetic(p yamlPath, s Synthetic) {
+	if s.Interval <= 0 {
+		v.errorf(p.key("interval"), "must be positive, got %s", s.Interval)
+	}
+	if s.Bars <= 0 {
+		v.errorf(p.key("bars"), "must be positive, got %d", s.Bars)
+	}
+
+	for _, symbol := range slices.Sorted(maps.Keys(s.Series)) {
+		series := s.Series[symbol]
+		sp := p.key("series").key(symbol)
+		if series.Path == "" {
+			v.errorf(sp.key("path"), "a path is required")
+		}
+		if series.Price <= 0 {
+			v.errorf(sp.key("price"), "must be positive, got %g", series.Price)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readValidationErrors(t *testing.T, yaml string) ValidationError {
+	t.Helper()
+
+	cfg, err := Read(strings.NewReader(yaml))
+	require.NoError(t, err)
+
+	err = cfg.Validate()
+	if err == nil {
+		return nil
+	}
+
+	var invalid ValidationError
+	require.ErrorAs(t, err, &invalid)
+	return invalid
+}
+
+func TestValidate_valid(t *testing.T) {
+	data := filepath.Join(t.TempDir(), "btc.csv")
+	require.NoError(t, os.WriteFile(data, nil, 0o644))
+
+	violations := readValidationErrors(t, `
+strategies:
+  BTC:
+    budget: 1000
+    buy_confidence: 0.8
+    sell_confidence: 1.1
+    take_profit: 1.02
+    stop_loss: 0.98
+    position_scale: 1
+    market_buffer: 39
+    prefetch: 195
+    aggregate_bars: 5
+    debug_level: 1
+    debug_window: 30
+    indicator:
+      ensemble:
+        - weight: 1
+          indicator:
+            rsi:
+              period: 7
+              overbought: 0.6
+              timeframe: 15m
+        - weight: 1
+          indicator:
+            macd:
+              fast: 6
+              slow: 13
+              signal: 5
+              buy_threshold: 1.0
+              buy_cap: 2
+              sell_threshold: 1.0
+              sell_cap: -2
+              cross_lookback: 1
+              ema_warmup: 3
+benchmark:
+  symbol: BTC
+platform:
+  emulator:
+    data:
+      BTC: `+data+`
+    start: 2025-01-01T00:00:00Z
+    end: 2025-02-01T00:00:00Z
+    balance: 1000
+`)
+
+	assert.Empty(t, violations)
+}
+
+func TestValidate_violations(t *testing.T) {
+	violations := readValidationErrors(t, `
+strategies:
+  BTC:
+    budget: -100
+    take_profit: 1.02
+    stop_loss: 1.5
+    position_scale: 1
+    market_buffer: 20
+    prefetch: 30
+    debug_window: 50
+    indicator:
+      ensemble:
+        - weight: 1
+          indicator:
+            macd:
+              fast: 26
+              slow: 12
+              signal: 9
+              buy_cap: 1
+              sell_cap: -1
+              cross_lookback: 1
+              ema_warmup: 2
+        - weight: 1
+          indicator:
+            rsi:
+              period: 14
+              overbought: 0.7
+              transform: median
+report_format: pdf
+platform:
+  emulator:
+    data:
+      BTC: /nonexistent/btc.csv
+    start: 2025-02-01T00:00:00Z
+    end: 2025-01-01T00:00:00Z
+`)
+
+	expected := []Violation{
+		{Path: "strategies.BTC.budget", Line: 4, Message: "must be positive, got -100"},
+		{Path: "strategies.BTC.stop_loss", Line: 6, Message: "must be at least 0 and less than 1, got 1.5"},
+		{Path: "strategies.BTC.market_buffer", Line: 8, Message: "must hold the 52 bars the indicator needs, got 20"},
+		{Path: "strategies.BTC.prefetch", Line: 9, Message: "must cover the indicator lookback of 52 one-minute bars, got 30"},
+		{Path: "strategies.BTC.debug_window", Line: 10, Message: "cannot exceed market_buffer (20), got 50"},
+		{Path: "strategies.BTC.indicator.ensemble[0].indicator.macd.fast", Line: 16, Message: "must be less than slow (12), got 26"},
+		{Path: "strategies.BTC.indicator.ensemble[1].indicator.rsi.transform", Line: 28, Message: `unknown bar transform "median"`},
+		{Path: "report_format", Line: 29, Message: `unknown report format "pdf", expected json or html`},
+		{Path: "platform.emulator.data.BTC", Line: 33, Message: "no files match /nonexistent/btc.csv"},
+		{Path: "platform.emulator.start", Line: 34, Message: "must be before end (2025-01-01T00:00:00Z), got 2025-02-01T00:00:00Z"},
+	}
+	assert.Equal(t, expected, []Violation(violations))
+}
+
+func TestValidate_alpaca(t *testing.T) {
+	violations := readValidationErrors(t, `
+strategies:
+  BTC/USD:
+    budget: 100
+    take_profit: 1.1
+    position_scale: 1
+    market_buffer: 10
+    indicator:
+      ensemble: []
+platform:
+  alpaca:
+    secret: secret
+`)
+
+	assert.Equal(t, []Violation{
+		{Path: "strategies.BTC/USD.indicator.ensemble", Line: 9, Message: "must contain at least one indicator"},
+		{Path: "platform.alpaca.api_key", Line: 11, Message: "an api key is required"},
+	}, []Violation(violations))
+}
+
+func TestValidate_tickBars(t *testing.T) {
+	violations := readValidationErrors(t, `
+strategies:
+  BTC/USD:
+    budget: 100
+    take_profit: 1.1
+    position_scale: 1
+    market_buffer: 10
+    bars:
+      type: tick
+      size: 0.5
+    indicator:
+      rsi:
+        period: 7
+        overbought: 0.6
+platform:
+  alpaca:
+    api_key: key
+    secret: secret
+`)
+
+	assert.Equal(t, []Violation{
+		{Path: "strategies.BTC/USD.bars.size", Line: 10, Message: "must be a whole number of at least 1 for tick bars, got 0.5"},
+	}, []Violation(violations))
+}
+
+func TestValidateSynthetic(t *testing.T) {
+	cfg, err := Read(strings.NewReader(`
+synthetic:
+  interval: 1m
+  bars: 0
+  series:
+    BTC:
+      path: btc.csv
+      price: 100
+`))
+	require.NoError(t, err)
+	assert.True(t, cfg.SyntheticOnly())
+	assert.EqualError(t, cfg.ValidateSynthetic(), "invalid config:\nline 4: synthetic.bars: must be positive, got 0")
+
+	cfg.Synthetic.Bars = 10
+	assert.NoError(t, cfg.ValidateSynthetic())
+	assert.Error(t, cfg.Validate())
+
+	cfg, err = Read(strings.NewReader("strategies:\n  BTC:\n    budget: 100\n"))
+	require.NoError(t, err)
+	assert.False(t, cfg.SyntheticOnly())
+	assert.EqualError(t, cfg.ValidateSynthetic(), "invalid config:\nsynthetic: a synthetic section is required")
+}
+
+func TestValidate_withoutSource(t *testing.T) {
+	cfg := Config{
+		Strategies: map[string]Strategy{"BTC": {
+			Budget:        100,
+			TakeProfit:    1.1,
+			PositionScale: 1,
+			MarketBuffer:  100,
+			IndRef:        IndicatorReference{Indicator: RSI{Period: 14, Overbought: 0.7, Timeframe: time.Minute}},
+			AggregateBars: 5,
+		}},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.EqualError(t, err, "invalid config:\n"+
+		"strategies.BTC.indicator.rsi.timeframe: cannot be shorter than the strategy bars (5m0s), got 1m0s\n"+
+		"platform: a platform is required")
+}
+
+func TestIndicatorReference_Lookback(t *testing.T) {
+	macd := MACD{Fast: 12, Slow: 26, Signal: 9, EmaWarmup: 3}
+	rsi := RSI{Period: 14, Timeframe: time.Hour}
+	ensemble := Ensemble{
+		{Weight: 1, IndRef: IndicatorReference{Indicator: macd}},
+		{Weight: 1, IndRef: IndicatorReference{Indicator: rsi}},
+	}
+
+	tbl := []struct {
+		name        string
+		ind         Indicator
+		barDuration time.Duration
+		lookback    int
+		prefetch    int
+	}{
+		{name: "macd", ind: macd, barDuration: time.Minute, lookback: 78, prefetch: 78},
+		{name: "macd aggregated", ind: macd, barDuration: 5 * time.Minute, lookback: 78, prefetch: 390},
+		{name: "rsi timeframe", ind: rsi, barDuration: time.Minute, lookback: 14, prefetch: 840},
+		{name: "ensemble", ind: ensemble, barDuration: time.Minute, lookback: 78, prefetch: 840},
+		{name: "ensemble of ensembles", ind: Ensemble{{Weight: 1, IndRef: IndicatorReference{Indicator: ensemble}}}, barDuration: 0, lookback: 78, prefetch: 840},
+		{name: "none", ind: nil, lookback: 0, prefetch: 0},
+	}
+
+	for _, c := range tbl {
+		t.Run(c.name, func(t *testing.T) {
+			ref := IndicatorReference{Indicator: c.ind}
+			assert.Equal(t, c.lookback, ref.Lookback())
+			assert.Equal(t, c.prefetch, prefetchLookback(ref, c.barDuration))
+		})
+	}
+}
@@ -4,6 +4,7 @@ import "fmt"
 
 type tradingIndicator interface {
 	GetSignal() (s Signal, err error)
+	Trace() SignalTrace
 	DrawDebug(d *DebugPlot) error
 }
 
@@ -14,9 +15,16 @@ type WeightedIndicator struct {
 
 type EnsembleIndicator struct {
 	Children []WeightedIndicator
+	last     Signal
 }
 
 func (i *EnsembleIndicator) GetSignal() (s Signal, err error) {
+	defer func() {
+		if err == nil {
+			i.last = s
+		}
+	}()
+
 	var act float64
 	var totalWeight float64
 	for _, c := range i.Children {
@@ -50,6 +58,17 @@ func (i *EnsembleIndicator) GetSignal() (s Signal, err error) {
 	}, nil
 }
 
+func (i *EnsembleIndicator) Trace() SignalTrace {
+	t := newSignalTrace("ensemble", i.last)
+	for _, c := range i.Children {
+		child := c.Indicator.Trace()
+		child.Weight = c.Weight
+		t.Children = append(t.Children, child)
+	}
+
+	return t
+}
+
 func (i *EnsembleIndicator) DrawDebug(d *DebugPlot) error {
 	for _, c := range i.Children {
 		if err := c.Indicator.DrawDebug(d); err != nil {
@@ -17,6 +17,10 @@ func (m *mockTradingIndicator) GetSignal() (Signal, error) {
 	return m.signal, m.err
 }
 
+func (m *mockTradingIndicator) Trace() SignalTrace {
+	return newSignalTrace("mock", m.signal)
+}
+
 func (m *mockTradingIndicator) DrawDebug(_ *DebugPlot) error {
 	return nil
 }
@@ -157,3 +161,24 @@ func TestEnsemble_GetSignal(t *testing.T) {
 		})
 	}
 }
+
+func TestEnsemble_Trace(t *testing.T) {
+	i := &EnsembleIndicator{
+		Children: []WeightedIndicator{
+			{Weight: 2, Indicator: &mockTradingIndicator{signal: Signal{Act: ActBuy, Confidence: 0.9}}},
+			{Weight: 1, Indicator: &mockTradingIndicator{signal: Signal{Act: ActSell, Confidence: 0.6}}},
+		},
+	}
+
+	s, err := i.GetSignal()
+	assert.NoError(t, err)
+	assert.Equal(t, SignalTrace{
+		Indicator:  "ensemble",
+		Act:        "ACT_BUY",
+		Confidence: s.Confidence,
+		Children: []SignalTrace{
+			{Indicator: "mock", Weight: 2, Act: "ACT_BUY", Confidence: 0.9},
+			{Indicator: "mock", Weight: 1, Act: "ACT_SELL", Confidence: 0.6},
+		},
+	}, i.Trace())
+}
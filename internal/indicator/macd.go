@@ -14,6 +14,7 @@ type MACDIndicator struct {
 	cfg   config.MACD
 	bars  barsProvider
 	debug macdDebugData
+	last  Signal
 }
 
 type macdDebugData struct {
@@ -29,9 +30,15 @@ func NewMACD(cfg config.MACD, bars barsProvider) *MACDIndicator {
 }
 
 func (i *MACDIndicator) GetSignal() (s Signal, err error) {
+	defer func() {
+		if err == nil {
+			i.last = s
+		}
+	}()
+
 	s = Signal{ActHold, 1.0}
 
-	count := i.getRequiredDataPoints()
+	count := i.cfg.Lookback()
 	if !i.bars.HasBars(count) {
 		return
 	}
@@ -70,8 +77,8 @@ func (i *MACDIndicator) GetSignal() (s Signal, err error) {
 	return
 }
 
-func (i *MACDIndicator) getRequiredDataPoints() int {
-	return i.cfg.EmaWarmup * max(i.cfg.Fast, i.cfg.Slow, i.cfg.Signal)
+func (i *MACDIndicator) Trace() SignalTrace {
+	return newSignalTrace("macd", i.last)
 }
 
 func (i *MACDIndicator) DrawDebug(d *DebugPlot) error {
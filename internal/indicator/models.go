@@ -15,6 +15,22 @@ type Signal struct {
 	Confidence float64
 }
 
+type SignalTrace struct {
+	Indicator  string        `json:"indicator"`
+	Weight     float64       `json:"weight,omitempty"`
+	Act        string        `json:"act"`
+	Confidence float64       `json:"confidence"`
+	Children   []SignalTrace `json:"children,omitempty"`
+}
+
+func newSignalTrace(indicator string, s Signal) SignalTrace {
+	return SignalTrace{
+		Indicator:  indicator,
+		Act:        s.Act.String(),
+		Confidence: s.Confidence,
+	}
+}
+
 func (a Action) String() string {
 	switch a {
 	case 1:
@@ -13,6 +13,7 @@ type RSIIndictor struct {
 	cfg   config.RSI
 	bars  barsProvider
 	debug rsiDebugData
+	last  Signal
 }
 
 type rsiDebugData struct {
@@ -28,6 +29,12 @@ func NewRSI(cfg config.RSI, bars barsProvider) *RSIIndictor {
 }
 
 func (i *RSIIndictor) GetSignal() (s Signal, err error) {
+	defer func() {
+		if err == nil {
+			i.last = s
+		}
+	}()
+
 	s = Signal{ActHold, 1.0}
 
 	if !i.bars.HasBars(i.cfg.Period) {
@@ -66,6 +73,10 @@ func (i *RSIIndictor) GetSignal() (s Signal, err error) {
 	return
 }
 
+func (i *RSIIndictor) Trace() SignalTrace {
+	return newSignalTrace("rsi", i.last)
+}
+
 func (i *RSIIndictor) DrawDebug(d *DebugPlot) error {
 	p := plot.New()
 	p.Title.Text = "RSI"
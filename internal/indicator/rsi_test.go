@@ -3,8 +3,11 @@ package indicator
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/gamma-omg/trading-bot/internal/market"
+	"github.com/gamma-omg/trading-bot/internal/synth"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -69,3 +72,43 @@ func TestRSI_GetSignal(t *testing.T) {
 		})
 	}
 }
+
+func TestRSI_GetSignal_syntheticTrends(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	bars, err := synth.Bars(config.Synthetic{
+		Seed:     42,
+		Start:    start,
+		Interval: time.Hour,
+		Bars:     4000,
+		Series: map[string]config.SyntheticSeries{"BTC": {
+			Price:      100,
+			Volatility: 0.3,
+			Trends: []config.SyntheticTrend{
+				{Start: start.Add(1000 * time.Hour), End: start.Add(2000 * time.Hour), Drift: 15},
+				{Start: start.Add(3000 * time.Hour), End: start.Add(4000 * time.Hour), Drift: -15},
+			},
+		}},
+	}, "BTC")
+	require.NoError(t, err)
+
+	asset := market.NewAsset("BTC", 64)
+	rsi := NewRSI(config.RSI{Period: 14, Overbought: 0.7}, asset)
+
+	counts := map[string]map[Action]int{"up": {}, "down": {}}
+	for i, b := range bars {
+		asset.Receive(b)
+
+		s, err := rsi.GetSignal()
+		require.NoError(t, err)
+
+		switch {
+		case i >= 1100 && i < 2000:
+			counts["up"][s.Act]++
+		case i >= 3100:
+			counts["down"][s.Act]++
+		}
+	}
+
+	assert.Greater(t, counts["up"][ActSell], 3*counts["up"][ActBuy])
+	assert.Greater(t, counts["down"][ActBuy], 3*counts["down"][ActSell])
+}
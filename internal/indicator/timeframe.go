@@ -0,0 +1,53 @@
+package indicator
+
+import (
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/market"
+)
+
+type lastBarProvider interface {
+	GetLastBar() (market.Bar, error)
+}
+
+type TimeframeIndicator struct {
+	Indicator tradingIndicator
+	bars      lastBarProvider
+	last      time.Time
+	signal    Signal
+}
+
+func NewTimeframeIndicator(ind tradingIndicator, bars lastBarProvider) *TimeframeIndicator {
+	return &TimeframeIndicator{
+		Indicator: ind,
+		bars:      bars,
+		signal:    Signal{ActHold, 1.0},
+	}
+}
+
+func (i *TimeframeIndicator) GetSignal() (s Signal, err error) {
+	bar, err := i.bars.GetLastBar()
+	if err != nil || bar.Time.Equal(i.last) {
+		return i.signal, nil
+	}
+
+	s, err = i.Indicator.GetSignal()
+	if err != nil {
+		return
+	}
+
+	i.last = bar.Time
+	i.signal = s
+	return
+}
+
+func (i *TimeframeIndicator) Trace() SignalTrace {
+	t := i.Indicator.Trace()
+	t.Act = i.signal.Act.String()
+	t.Confidence = i.signal.Confidence
+	return t
+}
+
+func (i *TimeframeIndicator) DrawDebug(d *DebugPlot) error {
+	return i.Indicator.DrawDebug(d)
+}
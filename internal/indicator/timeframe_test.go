@@ -0,0 +1,48 @@
+package indicator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/market"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingIndicator struct {
+	mockTradingIndicator
+	calls int
+}
+
+func (c *countingIndicator) GetSignal() (Signal, error) {
+	c.calls++
+	return c.mockTradingIndicator.GetSignal()
+}
+
+func TestTimeframeIndicator(t *testing.T) {
+	asset := market.NewAsset("BTC", 8)
+	hourly := asset.Timeframe(time.Hour, time.Minute)
+
+	child := &countingIndicator{mockTradingIndicator: mockTradingIndicator{signal: Signal{ActBuy, 0.5}}}
+	ind := NewTimeframeIndicator(child, hourly)
+
+	for m := range 59 {
+		asset.Receive(market.Bar{Time: time.Unix(int64(m*60), 0)})
+		s, err := ind.GetSignal()
+		require.NoError(t, err)
+		assert.Equal(t, Signal{ActHold, 1.0}, s)
+	}
+	assert.Equal(t, 0, child.calls)
+
+	asset.Receive(market.Bar{Time: time.Unix(59*60, 0)})
+	s, err := ind.GetSignal()
+	require.NoError(t, err)
+	assert.Equal(t, Signal{ActBuy, 0.5}, s)
+
+	child.signal = Signal{ActSell, 1}
+	asset.Receive(market.Bar{Time: time.Unix(60*60, 0)})
+	s, err = ind.GetSignal()
+	require.NoError(t, err)
+	assert.Equal(t, Signal{ActBuy, 0.5}, s)
+	assert.Equal(t, 1, child.calls)
+}
@@ -0,0 +1,141 @@
+package market
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+func TickAggregator(n int) BarAggregator {
+	return thresholdAggregator(func() func(cur *Bar, b Bar) bool {
+		count := 0
+		return func(_ *Bar, _ Bar) bool {
+			count++
+			if count < n {
+				return false
+			}
+
+			count = 0
+			return true
+		}
+	})
+}
+
+func VolumeAggregator(volume decimal.Decimal) BarAggregator {
+	return thresholdAggregator(func() func(cur *Bar, b Bar) bool {
+		return func(cur *Bar, _ Bar) bool {
+			return cur.Volume.GreaterThanOrEqual(volume)
+		}
+	})
+}
+
+func DollarAggregator(notional decimal.Decimal) BarAggregator {
+	return thresholdAggregator(func() func(cur *Bar, b Bar) bool {
+		sum := decimal.Zero
+		return func(_ *Bar, b Bar) bool {
+			sum = sum.Add(b.Close.Mul(b.Volume))
+			if sum.LessThan(notional) {
+				return false
+			}
+
+			sum = decimal.Zero
+			return true
+		}
+	})
+}
+
+func RangeAggregator(size decimal.Decimal) BarAggregator {
+	return thresholdAggregator(func() func(cur *Bar, b Bar) bool {
+		return func(cur *Bar, _ Bar) bool {
+			return cur.High.Sub(cur.Low).GreaterThanOrEqual(size)
+		}
+	})
+}
+
+func RenkoAggregator(box decimal.Decimal) BarAggregator {
+	return func(bars <-chan Bar) <-chan Bar {
+		out := make(chan Bar)
+		go func() {
+			defer close(out)
+
+			var lo, hi decimal.Decimal
+			started := false
+			volume := decimal.Zero
+			for b := range bars {
+				if !started {
+					lo, hi = b.Close, b.Close
+					started = true
+				}
+
+				volume = volume.Add(b.Volume)
+				for {
+					open, cls, ok := nextRenkoBrick(b.Close, lo, hi, box)
+					if !ok {
+						break
+					}
+
+					brick := Bar{
+						Time:   b.Time,
+						Open:   open,
+						High:   decimal.Max(open, cls),
+						Low:    decimal.Min(open, cls),
+						Close:  cls,
+						Volume: volume,
+					}
+					lo, hi = brick.Low, brick.High
+					volume = decimal.Zero
+					out <- brick
+				}
+			}
+		}()
+
+		return out
+	}
+}
+
+func nextRenkoBrick(price, lo, hi, box decimal.Decimal) (open, close decimal.Decimal, ok bool) {
+	switch {
+	case price.GreaterThanOrEqual(hi.Add(box)):
+		return hi, hi.Add(box), true
+	case price.LessThanOrEqual(lo.Sub(box)):
+		return lo, lo.Sub(box), true
+	default:
+		return decimal.Zero, decimal.Zero, false
+	}
+}
+
+func thresholdAggregator(newDone func() func(cur *Bar, b Bar) bool) BarAggregator {
+	return func(bars <-chan Bar) <-chan Bar {
+		out := make(chan Bar)
+		go func() {
+			defer close(out)
+
+			done := newDone()
+			var cur *Bar
+			for b := range bars {
+				if cur == nil {
+					cur = &Bar{
+						Time: b.Time,
+						Open: b.Open,
+						High: b.High,
+						Low:  b.Low,
+					}
+				}
+
+				cur.Close = b.Close
+				cur.High = decimal.Max(cur.High, b.High)
+				cur.Low = decimal.Min(cur.Low, b.Low)
+				cur.Volume = cur.Volume.Add(b.Volume)
+
+				if done(cur, b) {
+					out <- *cur
+					cur = nil
+				}
+			}
+
+			if cur != nil {
+				out <- *cur
+			}
+		}()
+
+		return out
+	}
+}
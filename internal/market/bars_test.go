@@ -0,0 +1,93 @@
+package market
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTickAggregator(t *testing.T) {
+	in := []testBar{
+		{time: time.Unix(0, 0), o: 1, h: 3, l: 1, c: 2, v: 1},
+		{time: time.Unix(1, 0), o: 2, h: 4, l: 0.5, c: 3, v: 2},
+		{time: time.Unix(2, 0), o: 3, h: 3, l: 3, c: 3, v: 3},
+		{time: time.Unix(3, 0), o: 4, h: 5, l: 4, c: 5, v: 4},
+		{time: time.Unix(4, 0), o: 5, h: 6, l: 5, c: 6, v: 5},
+	}
+
+	assert.Equal(t, []testBar{
+		{time: time.Unix(0, 0), o: 1, h: 4, l: 0.5, c: 3, v: 3},
+		{time: time.Unix(2, 0), o: 3, h: 5, l: 3, c: 5, v: 7},
+		{time: time.Unix(4, 0), o: 5, h: 6, l: 5, c: 6, v: 5},
+	}, runAggregator(TickAggregator(2), in))
+}
+
+func TestVolumeAggregator(t *testing.T) {
+	in := []testBar{
+		{time: time.Unix(0, 0), o: 1, h: 1, l: 1, c: 1, v: 4},
+		{time: time.Unix(1, 0), o: 2, h: 2, l: 2, c: 2, v: 7},
+		{time: time.Unix(2, 0), o: 3, h: 3, l: 3, c: 3, v: 12},
+		{time: time.Unix(3, 0), o: 4, h: 4, l: 4, c: 4, v: 1},
+	}
+
+	assert.Equal(t, []testBar{
+		{time: time.Unix(0, 0), o: 1, h: 2, l: 1, c: 2, v: 11},
+		{time: time.Unix(2, 0), o: 3, h: 3, l: 3, c: 3, v: 12},
+		{time: time.Unix(3, 0), o: 4, h: 4, l: 4, c: 4, v: 1},
+	}, runAggregator(VolumeAggregator(decimal.NewFromInt(10)), in))
+}
+
+func TestDollarAggregator(t *testing.T) {
+	in := []testBar{
+		{time: time.Unix(0, 0), o: 10, h: 10, l: 10, c: 10, v: 5},
+		{time: time.Unix(1, 0), o: 20, h: 20, l: 20, c: 20, v: 3},
+		{time: time.Unix(2, 0), o: 100, h: 100, l: 100, c: 100, v: 1},
+		{time: time.Unix(3, 0), o: 10, h: 10, l: 10, c: 10, v: 1},
+	}
+
+	assert.Equal(t, []testBar{
+		{time: time.Unix(0, 0), o: 10, h: 20, l: 10, c: 20, v: 8},
+		{time: time.Unix(2, 0), o: 100, h: 100, l: 100, c: 100, v: 1},
+		{time: time.Unix(3, 0), o: 10, h: 10, l: 10, c: 10, v: 1},
+	}, runAggregator(DollarAggregator(decimal.NewFromInt(100)), in))
+}
+
+func TestRangeAggregator(t *testing.T) {
+	in := []testBar{
+		{time: time.Unix(0, 0), o: 10, h: 11, l: 10, c: 11, v: 1},
+		{time: time.Unix(1, 0), o: 11, h: 12, l: 10.5, c: 12, v: 1},
+		{time: time.Unix(2, 0), o: 12, h: 12.5, l: 11.5, c: 12, v: 1},
+		{time: time.Unix(3, 0), o: 12, h: 14, l: 12, c: 14, v: 1},
+	}
+
+	assert.Equal(t, []testBar{
+		{time: time.Unix(0, 0), o: 10, h: 12, l: 10, c: 12, v: 2},
+		{time: time.Unix(2, 0), o: 12, h: 14, l: 11.5, c: 14, v: 2},
+	}, runAggregator(RangeAggregator(decimal.NewFromInt(2)), in))
+}
+
+func TestRenkoAggregator(t *testing.T) {
+	in := []testBar{
+		{time: time.Unix(0, 0), o: 100, h: 100, l: 100, c: 100, v: 1},
+		{time: time.Unix(1, 0), o: 100, h: 103, l: 100, c: 101, v: 1},
+		{time: time.Unix(2, 0), o: 101, h: 105, l: 101, c: 104.5, v: 1},
+		{time: time.Unix(3, 0), o: 104, h: 104, l: 101, c: 101, v: 1},
+		{time: time.Unix(4, 0), o: 101, h: 101, l: 99, c: 99, v: 1},
+	}
+
+	assert.Equal(t, []testBar{
+		{time: time.Unix(2, 0), o: 100, h: 102, l: 100, c: 102, v: 3},
+		{time: time.Unix(2, 0), o: 102, h: 104, l: 102, c: 104, v: 0},
+		{time: time.Unix(4, 0), o: 102, h: 102, l: 100, c: 100, v: 2},
+	}, runAggregator(RenkoAggregator(decimal.NewFromInt(2)), in))
+}
+
+func TestTickAggregator_independentRuns(t *testing.T) {
+	agg := TickAggregator(2)
+	in := []testBar{{time: time.Unix(0, 0), o: 1, h: 1, l: 1, c: 1, v: 1}}
+
+	assert.Len(t, runAggregator(agg, in), 1)
+	assert.Len(t, runAggregator(agg, append(in, in...)), 1)
+}
@@ -40,6 +40,7 @@ type Asset struct {
 	bars   []Bar
 	head   int
 	size   int
+	views  map[string]assetView
 }
 
 func NewAsset(symbol string, bufSize int) *Asset {
@@ -98,4 +99,8 @@ func (a *Asset) HasBars(count int) bool {
 func (a *Asset) Receive(bar Bar) {
 	a.head++
 	a.bars[a.head%a.size] = bar
+
+	for _, v := range a.views {
+		v.receive(bar)
+	}
 }
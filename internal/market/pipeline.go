@@ -0,0 +1,138 @@
+package market
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func ChainAggregators(aggs ...BarAggregator) BarAggregator {
+	return func(bars <-chan Bar) <-chan Bar {
+		for _, agg := range aggs {
+			bars = agg(bars)
+		}
+		return bars
+	}
+}
+
+func DedupeAggregator() BarAggregator {
+	return func(bars <-chan Bar) <-chan Bar {
+		out := make(chan Bar)
+		go func() {
+			defer close(out)
+
+			var last time.Time
+			for b := range bars {
+				if !last.IsZero() && !b.Time.After(last) {
+					continue
+				}
+
+				last = b.Time
+				out <- b
+			}
+		}()
+
+		return out
+	}
+}
+
+func GapFillAggregator(interval, maxGap time.Duration) BarAggregator {
+	return func(bars <-chan Bar) <-chan Bar {
+		out := make(chan Bar)
+		go func() {
+			defer close(out)
+
+			var prev *Bar
+			for b := range bars {
+				if prev != nil && (maxGap <= 0 || b.Time.Sub(prev.Time) <= maxGap) {
+					for t := prev.Time.Add(interval); t.Before(b.Time); t = t.Add(interval) {
+						out <- Bar{
+							Time:  t,
+							Open:  prev.Close,
+							High:  prev.Close,
+							Low:   prev.Close,
+							Close: prev.Close,
+						}
+					}
+				}
+
+				prev = &b
+				out <- b
+			}
+		}()
+
+		return out
+	}
+}
+
+// OutlierFilter accepts the new price level after reanchor consecutive outliers.
+func OutlierFilter(maxReturn float64, clip bool, reanchor int) BarAggregator {
+	limit := decimal.NewFromFloat(maxReturn)
+	return func(bars <-chan Bar) <-chan Bar {
+		out := make(chan Bar)
+		go func() {
+			defer close(out)
+
+			var prev *Bar
+			var rejected int
+			for b := range bars {
+				if prev != nil && maxReturn > 0 {
+					lo := prev.Close.Mul(decimal.NewFromInt(1).Sub(limit))
+					hi := prev.Close.Mul(decimal.NewFromInt(1).Add(limit))
+					if (b.Close.LessThan(lo) || b.Close.GreaterThan(hi)) && (reanchor <= 0 || rejected < reanchor) {
+						rejected++
+						if clip {
+							out <- clipBar(b, lo, hi)
+						}
+						continue
+					}
+				}
+
+				rejected = 0
+				prev = &b
+				out <- b
+			}
+		}()
+
+		return out
+	}
+}
+
+func InvalidBarFilter() BarAggregator {
+	return func(bars <-chan Bar) <-chan Bar {
+		out := make(chan Bar)
+		go func() {
+			defer close(out)
+
+			for b := range bars {
+				if isValidBar(b) {
+					out <- b
+				}
+			}
+		}()
+
+		return out
+	}
+}
+
+func isValidBar(b Bar) bool {
+	return b.Open.IsPositive() &&
+		b.High.IsPositive() &&
+		b.Low.IsPositive() &&
+		b.Close.IsPositive() &&
+		!b.High.LessThan(b.Low) &&
+		!b.Close.LessThan(b.Low) &&
+		!b.Close.GreaterThan(b.High)
+}
+
+func clipBar(b Bar, lo, hi decimal.Decimal) Bar {
+	clamp := func(d decimal.Decimal) decimal.Decimal {
+		return decimal.Min(decimal.Max(d, lo), hi)
+	}
+
+	b.Open = clamp(b.Open)
+	b.High = clamp(b.High)
+	b.Low = clamp(b.Low)
+	b.Close = clamp(b.Close)
+	return b
+}
@@ -0,0 +1,145 @@
+package market
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func runAggregator(agg BarAggregator, in []testBar) []testBar {
+	ch := make(chan Bar, len(in))
+	for _, b := range in {
+		ch <- b.ToBar()
+	}
+	close(ch)
+
+	var out []testBar
+	for b := range agg(ch) {
+		out = append(out, newTestBar(b))
+	}
+
+	return out
+}
+
+func TestDedupeAggregator(t *testing.T) {
+	in := []testBar{
+		{time: time.Unix(0, 0), o: 1, h: 1, l: 1, c: 1, v: 1},
+		{time: time.Unix(0, 0), o: 2, h: 2, l: 2, c: 2, v: 2},
+		{time: time.Unix(1, 0), o: 3, h: 3, l: 3, c: 3, v: 3},
+		{time: time.Unix(0, 0), o: 4, h: 4, l: 4, c: 4, v: 4},
+		{time: time.Unix(2, 0), o: 5, h: 5, l: 5, c: 5, v: 5},
+	}
+
+	assert.Equal(t, []testBar{in[0], in[2], in[4]}, runAggregator(DedupeAggregator(), in))
+}
+
+func TestGapFillAggregator(t *testing.T) {
+	tbl := []struct {
+		maxGap time.Duration
+		in     []testBar
+		out    []testBar
+	}{
+		{
+			in: []testBar{
+				{time: time.Unix(0, 0), o: 1, h: 2, l: 1, c: 2, v: 1},
+				{time: time.Unix(180, 0), o: 3, h: 3, l: 3, c: 3, v: 3},
+				{time: time.Unix(240, 0), o: 4, h: 4, l: 4, c: 4, v: 4},
+			},
+			out: []testBar{
+				{time: time.Unix(0, 0), o: 1, h: 2, l: 1, c: 2, v: 1},
+				{time: time.Unix(60, 0), o: 2, h: 2, l: 2, c: 2},
+				{time: time.Unix(120, 0), o: 2, h: 2, l: 2, c: 2},
+				{time: time.Unix(180, 0), o: 3, h: 3, l: 3, c: 3, v: 3},
+				{time: time.Unix(240, 0), o: 4, h: 4, l: 4, c: 4, v: 4},
+			},
+		},
+		{
+			maxGap: 2 * time.Minute,
+			in: []testBar{
+				{time: time.Unix(0, 0), o: 1, h: 1, l: 1, c: 1, v: 1},
+				{time: time.Unix(120, 0), o: 2, h: 2, l: 2, c: 2, v: 2},
+				{time: time.Unix(600, 0), o: 3, h: 3, l: 3, c: 3, v: 3},
+			},
+			out: []testBar{
+				{time: time.Unix(0, 0), o: 1, h: 1, l: 1, c: 1, v: 1},
+				{time: time.Unix(60, 0), o: 1, h: 1, l: 1, c: 1},
+				{time: time.Unix(120, 0), o: 2, h: 2, l: 2, c: 2, v: 2},
+				{time: time.Unix(600, 0), o: 3, h: 3, l: 3, c: 3, v: 3},
+			},
+		},
+	}
+
+	for i, c := range tbl {
+		t.Run(fmt.Sprintf("case_%d", i), func(t *testing.T) {
+			assert.Equal(t, c.out, runAggregator(GapFillAggregator(time.Minute, c.maxGap), c.in))
+		})
+	}
+}
+
+func TestOutlierFilter(t *testing.T) {
+	in := []testBar{
+		{time: time.Unix(0, 0), o: 100, h: 101, l: 99, c: 100, v: 1},
+		{time: time.Unix(1, 0), o: 100, h: 150, l: 100, c: 150, v: 1},
+		{time: time.Unix(2, 0), o: 100, h: 105, l: 99, c: 104, v: 1},
+	}
+
+	assert.Equal(t, []testBar{in[0], in[2]}, runAggregator(OutlierFilter(0.1, false, 3), in))
+
+	clipped := runAggregator(OutlierFilter(0.1, true, 3), in)
+	assert.Equal(t, []testBar{
+		in[0],
+		{time: time.Unix(1, 0), o: 100, h: 110, l: 100, c: 110, v: 1},
+		in[2],
+	}, clipped)
+}
+
+func TestOutlierFilter_levelShift(t *testing.T) {
+	in := []testBar{
+		{time: time.Unix(0, 0), o: 100, h: 100, l: 100, c: 100, v: 1},
+		{time: time.Unix(1, 0), o: 200, h: 200, l: 200, c: 200, v: 1},
+		{time: time.Unix(2, 0), o: 201, h: 201, l: 201, c: 201, v: 1},
+		{time: time.Unix(3, 0), o: 202, h: 202, l: 202, c: 202, v: 1},
+		{time: time.Unix(4, 0), o: 203, h: 203, l: 203, c: 203, v: 1},
+	}
+
+	assert.Equal(t, []testBar{in[0], in[3], in[4]}, runAggregator(OutlierFilter(0.1, false, 2), in))
+
+	assert.Equal(t, []testBar{
+		in[0],
+		{time: time.Unix(1, 0), o: 110, h: 110, l: 110, c: 110, v: 1},
+		{time: time.Unix(2, 0), o: 110, h: 110, l: 110, c: 110, v: 1},
+		in[3],
+		in[4],
+	}, runAggregator(OutlierFilter(0.1, true, 2), in))
+
+	assert.Equal(t, []testBar{in[0]}, runAggregator(OutlierFilter(0.1, false, 0), in))
+}
+
+func TestInvalidBarFilter(t *testing.T) {
+	in := []testBar{
+		{time: time.Unix(0, 0), o: 100, h: 101, l: 99, c: 100, v: 1},
+		{time: time.Unix(1, 0), o: 100, h: 99, l: 101, c: 100, v: 1},
+		{time: time.Unix(2, 0), o: 0, h: 101, l: 0, c: 100, v: 1},
+		{time: time.Unix(3, 0), o: 100, h: 101, l: 99, c: 102, v: 1},
+		{time: time.Unix(4, 0), o: 100, h: 105, l: 99, c: 104, v: 1},
+	}
+
+	assert.Equal(t, []testBar{in[0], in[4]}, runAggregator(InvalidBarFilter(), in))
+}
+
+func TestChainAggregators(t *testing.T) {
+	in := []testBar{
+		{time: time.Unix(0, 0), o: 1, h: 1, l: 1, c: 1, v: 1},
+		{time: time.Unix(0, 0), o: 1, h: 1, l: 1, c: 1, v: 1},
+		{time: time.Unix(120, 0), o: 2, h: 2, l: 2, c: 2, v: 2},
+	}
+
+	agg := ChainAggregators(DedupeAggregator(), GapFillAggregator(time.Minute, 0))
+	assert.Equal(t, []testBar{
+		in[0],
+		{time: time.Unix(60, 0), o: 1, h: 1, l: 1, c: 1},
+		in[2],
+	}, runAggregator(agg, in))
+}
@@ -0,0 +1,62 @@
+package market
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type timeframeBuilder struct {
+	interval    time.Duration
+	barDuration time.Duration
+	out         *Asset
+	cur         *Bar
+	end         time.Time
+}
+
+func (a *Asset) Timeframe(interval, barDuration time.Duration) *Asset {
+	key := fmt.Sprintf("timeframe:%s", interval)
+	if v, ok := a.views[key]; ok {
+		return v.asset
+	}
+
+	tb := &timeframeBuilder{
+		interval:    interval,
+		barDuration: barDuration,
+		out:         NewAsset(a.Symbol, a.size),
+	}
+
+	return a.addView(key, tb.out, tb.receive)
+}
+
+func (tb *timeframeBuilder) receive(b Bar) {
+	if tb.cur != nil && !b.Time.Before(tb.end) {
+		tb.close()
+	}
+
+	if tb.cur == nil {
+		start := b.Time.Truncate(tb.interval)
+		tb.end = start.Add(tb.interval)
+		tb.cur = &Bar{
+			Time: start,
+			Open: b.Open,
+			High: b.High,
+			Low:  b.Low,
+		}
+	}
+
+	tb.cur.Close = b.Close
+	tb.cur.High = decimal.Max(tb.cur.High, b.High)
+	tb.cur.Low = decimal.Min(tb.cur.Low, b.Low)
+	tb.cur.Volume = tb.cur.Volume.Add(b.Volume)
+
+	if tb.barDuration > 0 && !b.Time.Add(tb.barDuration).Before(tb.end) {
+		tb.close()
+	}
+}
+
+func (tb *timeframeBuilder) close() {
+	tb.out.Receive(*tb.cur)
+	tb.cur = nil
+}
@@ -0,0 +1,120 @@
+package market
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	TransformRaw        = ""
+	TransformHeikinAshi = "heikin_ashi"
+	TransformLog        = "log"
+	TransformTypical    = "typical"
+)
+
+// BarTransform reports false for bars it can not transform, those bars are skipped.
+type BarTransform func(b Bar) (Bar, bool)
+
+type assetView struct {
+	asset   *Asset
+	receive func(b Bar)
+}
+
+func NewBarTransform(name string) (BarTransform, error) {
+	switch name {
+	case TransformHeikinAshi:
+		return heikinAshi(), nil
+	case TransformLog:
+		return logPrice, nil
+	case TransformTypical:
+		return typicalPrice, nil
+	default:
+		return nil, fmt.Errorf("unknown bar transform: %s", name)
+	}
+}
+
+func (a *Asset) View(transform string) (*Asset, error) {
+	if transform == TransformRaw || transform == "raw" {
+		return a, nil
+	}
+
+	key := "transform:" + transform
+	if v, ok := a.views[key]; ok {
+		return v.asset, nil
+	}
+
+	t, err := NewBarTransform(transform)
+	if err != nil {
+		return nil, err
+	}
+
+	out := NewAsset(a.Symbol, a.size)
+	return a.addView(key, out, func(b Bar) {
+		if tb, ok := t(b); ok {
+			out.Receive(tb)
+		}
+	}), nil
+}
+
+func (a *Asset) addView(key string, out *Asset, receive func(b Bar)) *Asset {
+	for i := max(0, a.head-a.size+1); i <= a.head; i++ {
+		receive(a.bars[i%a.size])
+	}
+
+	if a.views == nil {
+		a.views = make(map[string]assetView)
+	}
+	a.views[key] = assetView{asset: out, receive: receive}
+
+	return out
+}
+
+func heikinAshi() BarTransform {
+	var prev *Bar
+	two := decimal.NewFromInt(2)
+	four := decimal.NewFromInt(4)
+
+	return func(b Bar) (Bar, bool) {
+		ha := Bar{
+			Time:   b.Time,
+			Close:  b.Open.Add(b.High).Add(b.Low).Add(b.Close).Div(four),
+			Volume: b.Volume,
+		}
+
+		if prev == nil {
+			ha.Open = b.Open.Add(b.Close).Div(two)
+		} else {
+			ha.Open = prev.Open.Add(prev.Close).Div(two)
+		}
+
+		ha.High = decimal.Max(b.High, ha.Open, ha.Close)
+		ha.Low = decimal.Min(b.Low, ha.Open, ha.Close)
+
+		prev = &ha
+		return ha, true
+	}
+}
+
+func logPrice(b Bar) (Bar, bool) {
+	if !b.Open.IsPositive() || !b.High.IsPositive() || !b.Low.IsPositive() || !b.Close.IsPositive() {
+		return b, false
+	}
+
+	ln := func(d decimal.Decimal) decimal.Decimal {
+		f, _ := d.Float64()
+		return decimal.NewFromFloat(math.Log(f))
+	}
+
+	b.Open = ln(b.Open)
+	b.High = ln(b.High)
+	b.Low = ln(b.Low)
+	b.Close = ln(b.Close)
+	return b, true
+}
+
+func typicalPrice(b Bar) (Bar, bool) {
+	b.Close = b.High.Add(b.Low).Add(b.Close).Div(decimal.NewFromInt(3))
+	return b, true
+}
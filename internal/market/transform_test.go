@@ -0,0 +1,117 @@
+package market
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeikinAshi(t *testing.T) {
+	transform := heikinAshi()
+	ha := func(b Bar) Bar {
+		out, ok := transform(b)
+		require.True(t, ok)
+		return out
+	}
+
+	first := newTestBar(ha((&testBar{time: time.Unix(0, 0), o: 10, h: 14, l: 8, c: 12, v: 1}).ToBar()))
+	assert.Equal(t, testBar{time: time.Unix(0, 0), o: 11, h: 14, l: 8, c: 11, v: 1}, first)
+
+	second := newTestBar(ha((&testBar{time: time.Unix(60, 0), o: 12, h: 13, l: 11, c: 12, v: 2}).ToBar()))
+	assert.Equal(t, testBar{time: time.Unix(60, 0), o: 11, h: 13, l: 11, c: 12, v: 2}, second)
+}
+
+func TestLogAndTypicalPrice(t *testing.T) {
+	b := (&testBar{o: 1, h: math.E, l: 1, c: math.E * math.E, v: 5}).ToBar()
+
+	lb, ok := logPrice(b)
+	require.True(t, ok)
+	l := newTestBar(lb)
+	assert.InDelta(t, 0, l.o, 1e-9)
+	assert.InDelta(t, 1, l.h, 1e-9)
+	assert.InDelta(t, 2, l.c, 1e-9)
+	assert.Equal(t, 5.0, l.v)
+
+	tb, ok := typicalPrice((&testBar{o: 1, h: 6, l: 3, c: 3}).ToBar())
+	require.True(t, ok)
+	assert.Equal(t, testBar{o: 1, h: 6, l: 3, c: 4}, newTestBar(tb))
+}
+
+func TestLogPrice_nonPositive(t *testing.T) {
+	for _, b := range []testBar{
+		{o: 0, h: 2, l: 0, c: 1},
+		{o: 1, h: 2, l: -1, c: 1},
+		{o: 1, h: 1, l: 1, c: 0},
+	} {
+		_, ok := logPrice(b.ToBar())
+		assert.False(t, ok)
+	}
+
+	a := NewAsset("BTC", 4)
+	v, err := a.View(TransformLog)
+	require.NoError(t, err)
+
+	a.Receive((&testBar{time: time.Unix(0, 0), o: 1, h: 1, l: 1, c: 1}).ToBar())
+	a.Receive((&testBar{time: time.Unix(60, 0), o: 1, h: 1, l: 0, c: 0}).ToBar())
+	assert.True(t, v.HasBars(1))
+	assert.False(t, v.HasBars(2))
+	assert.True(t, a.HasBars(2))
+}
+
+func TestAssetView(t *testing.T) {
+	a := NewAsset("BTC", 4)
+	a.Receive((&testBar{time: time.Unix(0, 0), o: 1, h: 6, l: 3, c: 3}).ToBar())
+
+	raw, err := a.View(TransformRaw)
+	require.NoError(t, err)
+	assert.Same(t, a, raw)
+
+	v, err := a.View(TransformTypical)
+	require.NoError(t, err)
+	again, err := a.View(TransformTypical)
+	require.NoError(t, err)
+	assert.Same(t, v, again)
+
+	a.Receive((&testBar{time: time.Unix(60, 0), o: 1, h: 9, l: 3, c: 6}).ToBar())
+
+	bars, err := v.GetBars(2)
+	require.NoError(t, err)
+	assert.Equal(t, 4.0, newTestBar(bars[0]).c)
+	assert.Equal(t, 6.0, newTestBar(bars[1]).c)
+
+	last, err := a.GetLastBar()
+	require.NoError(t, err)
+	assert.Equal(t, 6.0, newTestBar(last).c)
+
+	_, err = a.View("smoothed")
+	assert.Error(t, err)
+}
+
+func TestAssetTimeframe(t *testing.T) {
+	a := NewAsset("BTC", 8)
+	a.Receive((&testBar{time: time.Unix(0, 0), o: 1, h: 2, l: 1, c: 2, v: 1}).ToBar())
+
+	tf := a.Timeframe(3*time.Minute, time.Minute)
+	assert.Same(t, tf, a.Timeframe(3*time.Minute, time.Minute))
+	assert.False(t, tf.HasBars(1))
+
+	a.Receive((&testBar{time: time.Unix(60, 0), o: 2, h: 5, l: 2, c: 4, v: 1}).ToBar())
+	assert.False(t, tf.HasBars(1))
+
+	a.Receive((&testBar{time: time.Unix(120, 0), o: 4, h: 4, l: 0.5, c: 3, v: 1}).ToBar())
+	bars, err := tf.GetBars(1)
+	require.NoError(t, err)
+	assert.Equal(t, testBar{time: time.Unix(0, 0), o: 1, h: 5, l: 0.5, c: 3, v: 3}, newTestBar(bars[0]))
+
+	a.Receive((&testBar{time: time.Unix(240, 0), o: 3, h: 3, l: 3, c: 3, v: 1}).ToBar())
+	assert.False(t, tf.HasBars(2))
+
+	noDuration := a.Timeframe(2*time.Minute, 0)
+	bars, err = noDuration.GetBars(2)
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(0, 0), bars[0].Time)
+	assert.Equal(t, time.Unix(120, 0), bars[1].Time)
+}
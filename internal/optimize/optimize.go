@@ -0,0 +1,344 @@
+package optimize
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/agent"
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"golang.org/x/sync/errgroup"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ModeGrid   = "grid"
+	ModeRandom = "random"
+
+	OrderDesc = "desc"
+	OrderAsc  = "asc"
+)
+
+var topLevelKeys = []string{"strategies", "report", "report_format", "journal", "metrics", "equity", "benchmark", "synthetic", "platform"}
+
+// creatableKeys are top-level sections whose parameters may be missing from the base config.
+var creatableKeys = []string{"synthetic"}
+
+type Options struct {
+	Params  []Param
+	Metric  string
+	Order   string
+	Mode    string
+	Samples int
+	Seed    uint64
+	Workers int
+	Dir     string
+}
+
+type Result struct {
+	Run     int
+	Values  []string
+	Metrics map[string]*float64
+	Err     error
+}
+
+type runner func(ctx context.Context, cfg config.Config) (agent.JsonReport, error)
+
+type Optimizer struct {
+	log         *slog.Logger
+	base        *yaml.Node
+	opts        Options
+	run         runner
+	start       time.Time
+	end         time.Time
+	outOfSample bool
+}
+
+func NewOptimizer(log *slog.Logger, base []byte, opts Options) (*Optimizer, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(base, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse base config: %w", err)
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("base config must be a yaml mapping")
+	}
+
+	if len(opts.Params) == 0 {
+		return nil, errors.New("at least one parameter is required")
+	}
+	if !slices.Contains(agent.ReportMetricNames, opts.Metric) {
+		return nil, fmt.Errorf("unknown metric: %s", opts.Metric)
+	}
+
+	switch opts.Order {
+	case "":
+		opts.Order = OrderDesc
+	case OrderDesc, OrderAsc:
+	default:
+		return nil, fmt.Errorf("unknown order: %s", opts.Order)
+	}
+
+	switch opts.Mode {
+	case "":
+		opts.Mode = ModeGrid
+	case ModeGrid:
+	case ModeRandom:
+		if opts.Samples <= 0 {
+			return nil, errors.New("random mode requires a positive number of samples")
+		}
+	default:
+		return nil, fmt.Errorf("unknown mode: %s", opts.Mode)
+	}
+
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+
+	return &Optimizer{
+		log:  log,
+		base: root.Content[0],
+		opts: opts,
+		run:  runBacktest(log),
+	}, nil
+}
+
+func (o *Optimizer) Run(ctx context.Context) ([]Result, error) {
+	var combos [][]string
+	if o.opts.Mode == ModeRandom {
+		combos = sample(o.opts.Params, o.opts.Samples, rand.New(rand.NewPCG(o.opts.Seed, o.opts.Seed)))
+	} else {
+		combos = grid(o.opts.Params)
+	}
+
+	results := make([]Result, len(combos))
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(o.opts.Workers)
+	for i, values := range combos {
+		g.Go(func() error {
+			results[i] = o.runOne(ctx, i+1, values)
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			if results[i].Err != nil {
+				o.log.Warn("optimizer run failed", slog.Int("run", i+1), slog.Any("error", results[i].Err))
+			} else {
+				o.log.Info("optimizer run finished", slog.Int("run", i+1), slog.Int("total", len(combos)))
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, fmt.Errorf("optimizer interrupted: %w", err)
+	}
+
+	o.rank(results)
+	return results, nil
+}
+
+func (o *Optimizer) runOne(ctx context.Context, run int, values []string) Result {
+	res := Result{Run: run, Values: values}
+
+	cfg, err := o.config(run, values)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	report, err := o.run(ctx, *cfg)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	res.Metrics = agent.ReportMetrics(report)
+	return res
+}
+
+func (o *Optimizer) config(run int, values []string) (*config.Config, error) {
+	root := copyNode(o.base)
+	for i, p := range o.opts.Params {
+		if err := setParam(root, p.Path, values[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := yaml.Marshal(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode run config: %w", err)
+	}
+
+	cfg, err := config.Read(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	emu, ok := cfg.PlatformRef.Platform.(config.Emulator)
+	if !ok {
+		return nil, errors.New("optimizer requires the emulator platform")
+	}
+	emu.Replay = nil
+	if !o.start.IsZero() {
+		emu.Start = o.start
+		emu.End = o.end
+	}
+	if o.outOfSample {
+		emu.ClosePositions = true
+	}
+	cfg.PlatformRef.Platform = emu
+
+	name := fmt.Sprintf("run_%04d", run)
+	cfg.Report = filepath.Join(o.opts.Dir, name+".json")
+	cfg.ReportFormat = agent.ReportFormatJson
+	cfg.Journal = ""
+	cfg.Equity = nil
+	for symbol, s := range cfg.Strategies {
+		s.DebugLevel = config.DebugNone
+		s.DebugDir = filepath.Join(o.opts.Dir, name, "debug", symbol)
+		s.DataDump = ""
+		if o.outOfSample {
+			// out-of-sample runs warm the indicators up on the bars before the window
+			s.Prefetch = max(s.Prefetch, s.WarmupBars())
+		}
+		cfg.Strategies[symbol] = s
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func setParam(root *yaml.Node, path, value string) error {
+	segments, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+
+	if slices.Contains(topLevelKeys, segments[0].key) {
+		return setPath(root, path, value, slices.Contains(creatableKeys, segments[0].key))
+	}
+
+	strategies := mappingValue(root, "strategies")
+	if strategies == nil || strategies.Kind != yaml.MappingNode || len(strategies.Content) == 0 {
+		return fmt.Errorf("failed to set %s: config has no strategies", path)
+	}
+
+	for i := 1; i < len(strategies.Content); i += 2 {
+		if err := setPath(strategies.Content[i], path, value, false); err != nil {
+			return fmt.Errorf("failed to set %s for %s: %w", path, strategies.Content[i-1].Value, err)
+		}
+	}
+
+	return nil
+}
+
+func (o *Optimizer) rank(results []Result) {
+	metric := func(r Result) *float64 {
+		if r.Err != nil {
+			return nil
+		}
+		return r.Metrics[o.opts.Metric]
+	}
+
+	slices.SortStableFunc(results, func(a, b Result) int {
+		ma, mb := metric(a), metric(b)
+		switch {
+		case ma == nil && mb == nil:
+			return a.Run - b.Run
+		case ma == nil:
+			return 1
+		case mb == nil:
+			return -1
+		}
+
+		c := 0
+		if *ma < *mb {
+			c = -1
+		} else if *ma > *mb {
+			c = 1
+		}
+		if o.opts.Order == OrderDesc {
+			c = -c
+		}
+		if c == 0 {
+			return a.Run - b.Run
+		}
+		return c
+	})
+}
+
+func (o *Optimizer) WriteCsv(w io.Writer, results []Result) error {
+	cw := csv.NewWriter(w)
+
+	header := []string{"rank", "run"}
+	for _, p := range o.opts.Params {
+		header = append(header, p.Path)
+	}
+	header = append(header, agent.ReportMetricNames...)
+	header = append(header, "error")
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("failed to write results header: %w", err)
+	}
+
+	for i, r := range results {
+		row := []string{strconv.Itoa(i + 1), strconv.Itoa(r.Run)}
+		row = append(row, r.Values...)
+		for _, name := range agent.ReportMetricNames {
+			v := ""
+			if m := r.Metrics[name]; m != nil {
+				v = strconv.FormatFloat(*m, 'g', -1, 64)
+			}
+			row = append(row, v)
+		}
+
+		errMsg := ""
+		if r.Err != nil {
+			errMsg = r.Err.Error()
+		}
+		row = append(row, errMsg)
+
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("failed to write results row: %w", err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write results: %w", err)
+	}
+
+	return nil
+}
+
+func runBacktest(log *slog.Logger) runner {
+	return func(ctx context.Context, cfg config.Config) (agent.JsonReport, error) {
+		if err := os.MkdirAll(filepath.Dir(cfg.Report), os.ModePerm); err != nil {
+			return agent.JsonReport{}, fmt.Errorf("failed to create results directory: %w", err)
+		}
+
+		a, err := agent.NewTradingAgent(log, cfg, agent.NewJsonReportBuilder(log, cfg))
+		if err != nil {
+			return agent.JsonReport{}, fmt.Errorf("failed to create trading agent: %w", err)
+		}
+
+		if err := a.Run(ctx); err != nil {
+			return agent.JsonReport{}, fmt.Errorf("failed to run backtest: %w", err)
+		}
+
+		return agent.ReadJsonReport(cfg.Report)
+	}
+}
@@ -0,0 +1,295 @@
+package optimize
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gamma-omg/trading-bot/internal/agent"
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const baseConfig = `
+strategies:
+  BTC:
+    budget: 1000
+    take_profit: 1.02
+    position_scale: 1
+    market_buffer: 50
+    debug_level: 2
+    data_dump: dump.csv
+    indicator:
+      ensemble:
+        - weight: 1
+          indicator:
+            rsi:
+              period: 7
+              overbought: 0.7
+        - weight: 1
+          indicator:
+            macd:
+              fast: 6
+              slow: 13
+              signal: 5
+              buy_cap: 1
+              sell_cap: -1
+              cross_lookback: 1
+              ema_warmup: 3
+report: report.html
+journal: journal.jsonl
+equity:
+  interval: 1h
+platform:
+  emulator:
+    data:
+      BTC: btc.csv
+    start: 2025-01-01T00:00:00Z
+    end: 2025-01-07T00:00:00Z
+    balance: 1000
+    replay:
+      speed: 2x
+`
+
+func withTestData(t *testing.T, cfg string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "btc.csv")
+	require.NoError(t, os.WriteFile(path, nil, 0o644))
+	return strings.ReplaceAll(cfg, "btc.csv", path)
+}
+
+func newTestOptimizer(t *testing.T, opts Options, run runner) *Optimizer {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	o, err := NewOptimizer(log, []byte(withTestData(t, baseConfig)), opts)
+	require.NoError(t, err)
+	o.run = run
+	return o
+}
+
+func TestOptimizer_Run(t *testing.T) {
+	dir := t.TempDir()
+	fast := Param{Path: "indicator.ensemble[1].indicator.macd.fast", Values: []string{"4", "6", "8"}}
+	tp := Param{Path: "take_profit", Values: []string{"1.01", "1.02"}}
+
+	var mu sync.Mutex
+	var configs []config.Config
+	run := func(_ context.Context, cfg config.Config) (agent.JsonReport, error) {
+		mu.Lock()
+		configs = append(configs, cfg)
+		mu.Unlock()
+
+		s := cfg.Strategies["BTC"]
+		macd := s.IndRef.Indicator.(config.Ensemble)[1].IndRef.Indicator.(config.MACD)
+		if macd.Fast == 8 {
+			return agent.JsonReport{}, errors.New("boom")
+		}
+
+		sharpe := float64(macd.Fast) * s.TakeProfit
+		return agent.JsonReport{Metrics: &agent.JsonMetrics{Sharpe: &sharpe}}, nil
+	}
+
+	o := newTestOptimizer(t, Options{Params: []Param{fast, tp}, Metric: "sharpe", Workers: 2, Dir: dir}, run)
+	results, err := o.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, results, 6)
+	require.Len(t, configs, 6)
+
+	assert.Equal(t, []string{"6", "1.02"}, results[0].Values)
+	assert.Equal(t, []string{"6", "1.01"}, results[1].Values)
+	assert.Equal(t, []string{"4", "1.02"}, results[2].Values)
+	assert.Equal(t, []string{"4", "1.01"}, results[3].Values)
+	assert.Error(t, results[4].Err)
+	assert.Error(t, results[5].Err)
+
+	for _, cfg := range configs {
+		assert.Equal(t, dir, filepath.Dir(cfg.Report))
+		assert.Equal(t, agent.ReportFormatJson, cfg.ReportFormat)
+		assert.Empty(t, cfg.Journal)
+		assert.Nil(t, cfg.Equity)
+		assert.Nil(t, cfg.PlatformRef.Platform.(config.Emulator).Replay)
+		assert.Equal(t, config.DebugNone, cfg.Strategies["BTC"].DebugLevel)
+		assert.Empty(t, cfg.Strategies["BTC"].DataDump)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, o.WriteCsv(&buf, results))
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 7)
+
+	header := rows[0]
+	assert.Equal(t, []string{"rank", "run", fast.Path, tp.Path}, header[:4])
+	assert.Equal(t, "error", header[len(header)-1])
+	assert.Equal(t, []string{"1", "6", "1.02"}, []string{rows[1][0], rows[1][2], rows[1][3]})
+	assert.Equal(t, "boom", rows[6][len(header)-1])
+}
+
+func TestOptimizer_RunAscending(t *testing.T) {
+	run := func(_ context.Context, cfg config.Config) (agent.JsonReport, error) {
+		dd := cfg.Strategies["BTC"].TakeProfit - 1
+		return agent.JsonReport{Metrics: &agent.JsonMetrics{MaxDrawdown: dd}}, nil
+	}
+
+	o := newTestOptimizer(t, Options{
+		Params: []Param{{Path: "take_profit", Values: []string{"1.03", "1.01", "1.02"}}},
+		Metric: "max_drawdown",
+		Order:  OrderAsc,
+		Dir:    t.TempDir(),
+	}, run)
+
+	results, err := o.Run(context.Background())
+	require.NoError(t, err)
+
+	var values []string
+	for _, r := range results {
+		values = append(values, r.Values[0])
+	}
+	assert.Equal(t, []string{"1.01", "1.02", "1.03"}, values)
+}
+
+func TestOptimizer_RunRandom(t *testing.T) {
+	run := func(_ context.Context, _ config.Config) (agent.JsonReport, error) {
+		return agent.JsonReport{}, nil
+	}
+
+	opts := Options{
+		Params:  []Param{{Path: "take_profit", Values: []string{"1.01", "1.02", "1.03"}}},
+		Metric:  "sharpe",
+		Mode:    ModeRandom,
+		Samples: 4,
+		Seed:    42,
+		Dir:     t.TempDir(),
+	}
+
+	first, err := newTestOptimizer(t, opts, run).Run(context.Background())
+	require.NoError(t, err)
+	second, err := newTestOptimizer(t, opts, run).Run(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, first, 4)
+	assert.Equal(t, first, second)
+}
+
+func TestOptimizer_InvalidRunConfig(t *testing.T) {
+	var runs int
+	run := func(_ context.Context, cfg config.Config) (agent.JsonReport, error) {
+		runs++
+		sharpe := cfg.Strategies["BTC"].TakeProfit
+		return agent.JsonReport{Metrics: &agent.JsonMetrics{Sharpe: &sharpe}}, nil
+	}
+
+	o := newTestOptimizer(t, Options{
+		Params:  []Param{{Path: "take_profit", Values: []string{"1.02", "0.9"}}},
+		Metric:  "sharpe",
+		Workers: 1,
+		Dir:     t.TempDir(),
+	}, run)
+
+	results, err := o.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, 1, runs)
+	assert.NoError(t, results[0].Err)
+	assert.ErrorContains(t, results[1].Err, "strategies.BTC.take_profit: must be greater than 1, got 0.9")
+}
+
+func TestOptimizer_MisspelledParam(t *testing.T) {
+	run := func(_ context.Context, _ config.Config) (agent.JsonReport, error) {
+		t.Error("misspelled parameter must not run")
+		return agent.JsonReport{}, nil
+	}
+
+	for _, path := range []string{"indicator.ensemble[1].indicator.macd.fats", "report_fromat"} {
+		o := newTestOptimizer(t, Options{
+			Params: []Param{{Path: path, Values: []string{"5", "10"}}},
+			Metric: "sharpe",
+			Dir:    t.TempDir(),
+		}, run)
+
+		results, err := o.Run(context.Background())
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		for _, r := range results {
+			assert.ErrorContains(t, r.Err, "not found in the base config")
+		}
+	}
+}
+
+func TestOptimizer_SyntheticParam(t *testing.T) {
+	var seeds []uint64
+	run := func(_ context.Context, cfg config.Config) (agent.JsonReport, error) {
+		seeds = append(seeds, cfg.Synthetic.Seed)
+		return agent.JsonReport{}, nil
+	}
+
+	base := withTestData(t, baseConfig) + `
+synthetic:
+  seed: 1
+  interval: 1m
+  bars: 100
+`
+	o, err := NewOptimizer(slog.New(slog.NewTextHandler(io.Discard, nil)), []byte(base), Options{
+		Params:  []Param{{Path: "synthetic.seed", Values: []string{"7"}}},
+		Metric:  "sharpe",
+		Workers: 1,
+		Dir:     t.TempDir(),
+	})
+	require.NoError(t, err)
+	o.run = run
+
+	results, err := o.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, []uint64{7}, seeds)
+}
+
+func TestNewOptimizer_Errors(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	params := []Param{{Path: "take_profit", Values: []string{"1"}}}
+
+	tests := []struct {
+		name string
+		base string
+		opts Options
+	}{
+		{name: "no params", base: baseConfig, opts: Options{Metric: "sharpe"}},
+		{name: "unknown metric", base: baseConfig, opts: Options{Params: params, Metric: "luck"}},
+		{name: "unknown order", base: baseConfig, opts: Options{Params: params, Metric: "sharpe", Order: "up"}},
+		{name: "unknown mode", base: baseConfig, opts: Options{Params: params, Metric: "sharpe", Mode: "annealing"}},
+		{name: "random without samples", base: baseConfig, opts: Options{Params: params, Metric: "sharpe", Mode: ModeRandom}},
+		{name: "not a mapping", base: "- a\n- b\n", opts: Options{Params: params, Metric: "sharpe"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewOptimizer(log, []byte(tt.base), tt.opts)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestOptimizer_RequiresEmulator(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	base := "strategies:\n  BTC:\n    take_profit: 1\nplatform:\n  alpaca:\n    api_key: x\n"
+	o, err := NewOptimizer(log, []byte(base), Options{Params: []Param{{Path: "take_profit", Values: []string{"1.1"}}}, Metric: "sharpe"})
+	require.NoError(t, err)
+
+	results, err := o.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.ErrorContains(t, results[0].Err, "emulator")
+}
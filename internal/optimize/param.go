@@ -0,0 +1,235 @@
+package optimize
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Param struct {
+	Path   string
+	Values []string
+}
+
+func ParseParam(s string) (Param, error) {
+	path, spec, ok := strings.Cut(s, "=")
+	if !ok || path == "" || spec == "" {
+		return Param{}, fmt.Errorf("invalid parameter %q, expected path=min:max:step or path=v1,v2", s)
+	}
+
+	if _, err := parsePath(path); err != nil {
+		return Param{}, err
+	}
+
+	if parts := strings.Split(spec, ":"); len(parts) == 3 {
+		values, err := parseRange(parts[0], parts[1], parts[2])
+		if err != nil {
+			return Param{}, fmt.Errorf("invalid range for %s: %w", path, err)
+		}
+		return Param{Path: path, Values: values}, nil
+	}
+
+	var values []string
+	for _, v := range strings.Split(spec, ",") {
+		values = append(values, strings.TrimSpace(v))
+	}
+
+	return Param{Path: path, Values: values}, nil
+}
+
+func parseRange(minStr, maxStr, stepStr string) ([]string, error) {
+	lo, err := strconv.ParseFloat(minStr, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid min: %w", err)
+	}
+	hi, err := strconv.ParseFloat(maxStr, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid max: %w", err)
+	}
+	step, err := strconv.ParseFloat(stepStr, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid step: %w", err)
+	}
+	if step <= 0 || hi < lo {
+		return nil, errors.New("step must be positive and max not less than min")
+	}
+
+	isInt := isInteger(minStr) && isInteger(maxStr) && isInteger(stepStr)
+	n := int((hi-lo)/step+1e-9) + 1
+
+	values := make([]string, n)
+	for i := range n {
+		v := lo + float64(i)*step
+		if isInt {
+			values[i] = strconv.FormatInt(int64(v), 10)
+		} else {
+			values[i] = strconv.FormatFloat(roundTo(v, stepStr), 'f', -1, 64)
+		}
+	}
+
+	return values, nil
+}
+
+func roundTo(v float64, step string) float64 {
+	decimals := 0
+	if _, frac, ok := strings.Cut(step, "."); ok {
+		decimals = len(frac)
+	}
+
+	r, _ := strconv.ParseFloat(strconv.FormatFloat(v, 'f', decimals, 64), 64)
+	return r
+}
+
+func isInteger(s string) bool {
+	_, err := strconv.ParseInt(s, 10, 64)
+	return err == nil
+}
+
+func grid(params []Param) [][]string {
+	combos := [][]string{{}}
+	for _, p := range params {
+		next := make([][]string, 0, len(combos)*len(p.Values))
+		for _, c := range combos {
+			for _, v := range p.Values {
+				next = append(next, append(c[:len(c):len(c)], v))
+			}
+		}
+		combos = next
+	}
+
+	return combos
+}
+
+func sample(params []Param, n int, rng *rand.Rand) [][]string {
+	combos := make([][]string, n)
+	for i := range combos {
+		combos[i] = make([]string, len(params))
+		for j, p := range params {
+			combos[i][j] = p.Values[rng.IntN(len(p.Values))]
+		}
+	}
+
+	return combos
+}
+
+type pathSegment struct {
+	key   string
+	index int
+}
+
+func parsePath(path string) ([]pathSegment, error) {
+	var segments []pathSegment
+	for _, part := range strings.Split(path, ".") {
+		key, rest, _ := strings.Cut(part, "[")
+		if key == "" {
+			return nil, fmt.Errorf("invalid path %q", path)
+		}
+		segments = append(segments, pathSegment{key: key, index: -1})
+
+		for rest != "" {
+			idx, tail, ok := strings.Cut(rest, "]")
+			if !ok {
+				return nil, fmt.Errorf("invalid index in path %q", path)
+			}
+			i, err := strconv.Atoi(idx)
+			if err != nil || i < 0 {
+				return nil, fmt.Errorf("invalid index in path %q", path)
+			}
+			segments = append(segments, pathSegment{index: i})
+
+			rest = strings.TrimPrefix(tail, "[")
+			if tail != "" && !strings.HasPrefix(tail, "[") {
+				return nil, fmt.Errorf("invalid index in path %q", path)
+			}
+		}
+	}
+
+	return segments, nil
+}
+
+// setPath sets an existing field, with create missing mapping keys are added.
+func setPath(node *yaml.Node, path string, value string, create bool) error {
+	segments, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+
+	for i, s := range segments {
+		last := i == len(segments)-1
+		if s.index >= 0 {
+			if node.Kind != yaml.SequenceNode || s.index >= len(node.Content) {
+				return fmt.Errorf("failed to set %s: index %d out of range", path, s.index)
+			}
+			node = node.Content[s.index]
+			continue
+		}
+
+		if node.Kind != yaml.MappingNode {
+			return fmt.Errorf("failed to set %s: %s is not a mapping", path, s.key)
+		}
+
+		child := mappingValue(node, s.key)
+		if child == nil {
+			if !create {
+				return fmt.Errorf("failed to set %s: %s not found in the base config", path, s.key)
+			}
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s.key}, child)
+		}
+		if last {
+			child.Kind = yaml.ScalarNode
+			child.Tag = scalarTag(value)
+			child.Value = value
+			child.Content = nil
+		}
+		node = child
+	}
+
+	if segments[len(segments)-1].index >= 0 {
+		if node.Kind != yaml.ScalarNode {
+			return fmt.Errorf("failed to set %s: not a leaf field", path)
+		}
+		node.Tag = scalarTag(value)
+		node.Value = value
+	}
+
+	return nil
+}
+
+// copyNode deep copies a yaml tree, decoding into a yaml.Node shares the children.
+func copyNode(n *yaml.Node) *yaml.Node {
+	c := *n
+	c.Content = make([]*yaml.Node, len(n.Content))
+	for i, child := range n.Content {
+		c.Content[i] = copyNode(child)
+	}
+	return &c
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+func scalarTag(value string) string {
+	if isInteger(value) {
+		return "!!int"
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return "!!float"
+	}
+	if _, err := strconv.ParseBool(value); err == nil {
+		return "!!bool"
+	}
+
+	return "!!str"
+}
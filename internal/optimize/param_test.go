@@ -0,0 +1,141 @@
+package optimize
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseParam(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    Param
+		wantErr bool
+	}{
+		{
+			name: "int range",
+			in:   "indicator.ensemble[1].indicator.macd.fast=4:8:2",
+			want: Param{Path: "indicator.ensemble[1].indicator.macd.fast", Values: []string{"4", "6", "8"}},
+		},
+		{
+			name: "float range",
+			in:   "take_profit=1.01:1.03:0.01",
+			want: Param{Path: "take_profit", Values: []string{"1.01", "1.02", "1.03"}},
+		},
+		{
+			name: "list",
+			in:   "indicator.rsi.transform=linear, tanh",
+			want: Param{Path: "indicator.rsi.transform", Values: []string{"linear", "tanh"}},
+		},
+		{name: "missing values", in: "take_profit", wantErr: true},
+		{name: "negative step", in: "take_profit=1:2:-1", wantErr: true},
+		{name: "inverted range", in: "take_profit=2:1:1", wantErr: true},
+		{name: "bad index", in: "indicator.ensemble[x].weight=1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseParam(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, p)
+		})
+	}
+}
+
+func TestGrid(t *testing.T) {
+	combos := grid([]Param{
+		{Path: "a", Values: []string{"1", "2"}},
+		{Path: "b", Values: []string{"x", "y", "z"}},
+	})
+
+	assert.Equal(t, [][]string{
+		{"1", "x"}, {"1", "y"}, {"1", "z"},
+		{"2", "x"}, {"2", "y"}, {"2", "z"},
+	}, combos)
+}
+
+func TestSample(t *testing.T) {
+	params := []Param{
+		{Path: "a", Values: []string{"1", "2", "3"}},
+		{Path: "b", Values: []string{"x", "y"}},
+	}
+
+	first := sample(params, 5, rand.New(rand.NewPCG(7, 7)))
+	second := sample(params, 5, rand.New(rand.NewPCG(7, 7)))
+
+	require.Len(t, first, 5)
+	assert.Equal(t, first, second)
+	for _, c := range first {
+		assert.Contains(t, params[0].Values, c[0])
+		assert.Contains(t, params[1].Values, c[1])
+	}
+}
+
+func TestSetPath(t *testing.T) {
+	src := `
+indicator:
+  ensemble:
+    - weight: 1
+      indicator:
+        rsi:
+          period: 7
+    - weight: 1
+      indicator:
+        macd:
+          fast: 6
+`
+	var doc yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(src), &doc))
+	root := doc.Content[0]
+
+	require.NoError(t, setPath(root, "indicator.ensemble[1].indicator.macd.fast", "9", false))
+	require.NoError(t, setPath(root, "indicator.ensemble[0].weight", "0.5", false))
+	require.NoError(t, setPath(root, "take_profit", "1.05", true))
+
+	var out struct {
+		TakeProfit float64 `yaml:"take_profit"`
+		Indicator  struct {
+			Ensemble []struct {
+				Weight    float64 `yaml:"weight"`
+				Indicator struct {
+					MACD *struct {
+						Fast int `yaml:"fast"`
+					} `yaml:"macd"`
+				} `yaml:"indicator"`
+			} `yaml:"ensemble"`
+		} `yaml:"indicator"`
+	}
+	require.NoError(t, root.Decode(&out))
+
+	assert.Equal(t, 1.05, out.TakeProfit)
+	assert.Equal(t, 0.5, out.Indicator.Ensemble[0].Weight)
+	require.NotNil(t, out.Indicator.Ensemble[1].Indicator.MACD)
+	assert.Equal(t, 9, out.Indicator.Ensemble[1].Indicator.MACD.Fast)
+
+	assert.Error(t, setPath(root, "indicator.ensemble[5].weight", "1", false))
+	assert.Error(t, setPath(root, "indicator.ensemble.weight", "1", false))
+	assert.EqualError(t, setPath(root, "indicator.ensemble[1].indicator.macd.fats", "5", false),
+		"failed to set indicator.ensemble[1].indicator.macd.fats: fats not found in the base config")
+	assert.Error(t, setPath(root, "stop_loss", "0.9", false))
+}
+
+func TestCopyNode(t *testing.T) {
+	var doc yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("strategies:\n  BTC:\n    take_profit: 1.02\n"), &doc))
+	base := doc.Content[0]
+
+	c := copyNode(base)
+	require.NoError(t, setPath(c, "strategies.BTC.take_profit", "1.05", false))
+
+	assert.Equal(t, "1.02", mappingValue(mappingValue(mappingValue(base, "strategies"), "BTC"), "take_profit").Value)
+	assert.Equal(t, "1.05", mappingValue(mappingValue(mappingValue(c, "strategies"), "BTC"), "take_profit").Value)
+}
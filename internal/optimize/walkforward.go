@@ -0,0 +1,250 @@
+package optimize
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/agent"
+	"github.com/gamma-omg/trading-bot/internal/config"
+)
+
+type WalkForwardOptions struct {
+	InSample    time.Duration
+	OutOfSample time.Duration
+	Step        time.Duration
+}
+
+type WalkForwardReport struct {
+	agent.JsonReport
+	WalkForward WalkForwardSummary `json:"walk_forward"`
+}
+
+type WalkForwardSummary struct {
+	Metric    string              `json:"metric"`
+	Params    []string            `json:"params"`
+	Windows   []WalkForwardWindow `json:"windows"`
+	Stability []ParamStability    `json:"stability"`
+}
+
+type WalkForwardWindow struct {
+	InStart     time.Time `json:"in_start"`
+	InEnd       time.Time `json:"in_end"`
+	OutStart    time.Time `json:"out_start"`
+	OutEnd      time.Time `json:"out_end"`
+	Values      []string  `json:"values,omitempty"`
+	InSample    *float64  `json:"in_sample,omitempty"`
+	OutOfSample *float64  `json:"out_of_sample,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+type ParamStability struct {
+	Path      string   `json:"path"`
+	Values    []string `json:"values"`
+	Distinct  int      `json:"distinct"`
+	Mode      string   `json:"mode"`
+	ModeShare float64  `json:"mode_share"`
+	Mean      *float64 `json:"mean,omitempty"`
+	StdDev    *float64 `json:"std_dev,omitempty"`
+	Min       *float64 `json:"min,omitempty"`
+	Max       *float64 `json:"max,omitempty"`
+}
+
+type window struct {
+	inStart, inEnd   time.Time
+	outStart, outEnd time.Time
+}
+
+func (o *Optimizer) WalkForward(ctx context.Context, wf WalkForwardOptions) (WalkForwardReport, error) {
+	var base config.Config
+	if err := o.base.Decode(&base); err != nil {
+		return WalkForwardReport{}, fmt.Errorf("failed to parse base config: %w", err)
+	}
+
+	emu, ok := base.PlatformRef.Platform.(config.Emulator)
+	if !ok {
+		return WalkForwardReport{}, errors.New("walk-forward requires the emulator platform")
+	}
+
+	windows, err := splitWindows(emu.Start, emu.End, wf)
+	if err != nil {
+		return WalkForwardReport{}, err
+	}
+
+	summary := WalkForwardSummary{Metric: o.opts.Metric}
+	for _, p := range o.opts.Params {
+		summary.Params = append(summary.Params, p.Path)
+	}
+
+	var reports []agent.JsonReport
+	for i, w := range windows {
+		res, report, err := o.runWindow(ctx, i+1, w)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return WalkForwardReport{}, fmt.Errorf("walk-forward interrupted: %w", ctxErr)
+			}
+			res.Error = err.Error()
+			o.log.Warn("walk-forward window failed", slog.Int("window", i+1), slog.Any("error", err))
+		} else {
+			reports = append(reports, report)
+		}
+
+		summary.Windows = append(summary.Windows, res)
+	}
+
+	summary.Stability = paramStability(summary.Params, summary.Windows)
+
+	return WalkForwardReport{
+		JsonReport:  agent.MergeReports(o.log, base, reports...),
+		WalkForward: summary,
+	}, nil
+}
+
+func (o *Optimizer) runWindow(ctx context.Context, n int, w window) (WalkForwardWindow, agent.JsonReport, error) {
+	res := WalkForwardWindow{InStart: w.inStart, InEnd: w.inEnd, OutStart: w.outStart, OutEnd: w.outEnd}
+	dir := filepath.Join(o.opts.Dir, fmt.Sprintf("window_%03d", n))
+
+	in := o.withRange(w.inStart, w.inEnd, filepath.Join(dir, "in_sample"), false)
+	results, err := in.Run(ctx)
+	if err != nil {
+		return res, agent.JsonReport{}, err
+	}
+
+	best := results[0]
+	if best.Err != nil || best.Metrics[o.opts.Metric] == nil {
+		return res, agent.JsonReport{}, fmt.Errorf("no in-sample run produced %s", o.opts.Metric)
+	}
+	res.Values = best.Values
+	res.InSample = best.Metrics[o.opts.Metric]
+
+	out := o.withRange(w.outStart, w.outEnd, filepath.Join(dir, "out_of_sample"), true)
+	cfg, err := out.config(best.Run, best.Values)
+	if err != nil {
+		return res, agent.JsonReport{}, err
+	}
+
+	report, err := out.run(ctx, *cfg)
+	if err != nil {
+		return res, agent.JsonReport{}, fmt.Errorf("failed to run out-of-sample backtest: %w", err)
+	}
+
+	res.OutOfSample = agent.ReportMetrics(report)[o.opts.Metric]
+	o.log.Info("walk-forward window finished", slog.Int("window", n), slog.Time("out_start", w.outStart), slog.Time("out_end", w.outEnd))
+	return res, report, nil
+}
+
+func (o *Optimizer) withRange(start, end time.Time, dir string, outOfSample bool) *Optimizer {
+	c := *o
+	c.opts.Dir = dir
+	c.start = start
+	c.end = end
+	c.outOfSample = outOfSample
+	return &c
+}
+
+func splitWindows(start, end time.Time, wf WalkForwardOptions) ([]window, error) {
+	if wf.InSample <= 0 || wf.OutOfSample <= 0 {
+		return nil, errors.New("in-sample and out-of-sample windows must be positive")
+	}
+	if !start.Before(end) {
+		return nil, errors.New("emulator start must be before end")
+	}
+
+	step := wf.Step
+	if step <= 0 {
+		step = wf.OutOfSample
+	}
+	if step < wf.OutOfSample {
+		return nil, fmt.Errorf("step (%s) cannot be shorter than the out-of-sample window (%s), out-of-sample windows would overlap", step, wf.OutOfSample)
+	}
+
+	var windows []window
+	for s := start; s.Add(wf.InSample).Before(end); s = s.Add(step) {
+		w := window{inStart: s, inEnd: s.Add(wf.InSample), outStart: s.Add(wf.InSample)}
+		w.outEnd = w.outStart.Add(wf.OutOfSample)
+		if w.outEnd.After(end) {
+			w.outEnd = end
+		}
+		windows = append(windows, w)
+	}
+
+	if len(windows) == 0 {
+		return nil, errors.New("emulator range is shorter than the in-sample window")
+	}
+
+	return windows, nil
+}
+
+func paramStability(params []string, windows []WalkForwardWindow) []ParamStability {
+	stability := make([]ParamStability, len(params))
+	for i, path := range params {
+		s := ParamStability{Path: path}
+		counts := map[string]int{}
+		var numbers []float64
+		numeric := true
+		for _, w := range windows {
+			if w.Values == nil {
+				continue
+			}
+
+			v := w.Values[i]
+			s.Values = append(s.Values, v)
+			counts[v]++
+			if counts[v] > counts[s.Mode] || (counts[v] == counts[s.Mode] && v < s.Mode) {
+				s.Mode = v
+			}
+
+			f, err := strconv.ParseFloat(v, 64)
+			numeric = numeric && err == nil
+			numbers = append(numbers, f)
+		}
+
+		s.Distinct = len(counts)
+		if len(s.Values) > 0 {
+			s.ModeShare = float64(counts[s.Mode]) / float64(len(s.Values))
+		}
+
+		if numeric && len(numbers) > 0 {
+			mean, std := meanStdDev(numbers)
+			lo, hi := slices.Min(numbers), slices.Max(numbers)
+			s.Mean, s.StdDev, s.Min, s.Max = &mean, &std, &lo, &hi
+		}
+
+		stability[i] = s
+	}
+
+	return stability
+}
+
+func meanStdDev(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+
+	return mean, math.Sqrt(sq / float64(len(values)))
+}
+
+func WriteWalkForward(w io.Writer, r WalkForwardReport) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	if err := e.Encode(r); err != nil {
+		return fmt.Errorf("failed to write walk-forward report: %w", err)
+	}
+
+	return nil
+}
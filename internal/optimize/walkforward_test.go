@@ -0,0 +1,163 @@
+package optimize
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/agent"
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitWindows(t *testing.T) {
+	day := 24 * time.Hour
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	windows, err := splitWindows(start, start.Add(10*day), WalkForwardOptions{InSample: 4 * day, OutOfSample: 2 * day})
+	require.NoError(t, err)
+	require.Len(t, windows, 3)
+
+	assert.Equal(t, window{start, start.Add(4 * day), start.Add(4 * day), start.Add(6 * day)}, windows[0])
+	assert.Equal(t, window{start.Add(2 * day), start.Add(6 * day), start.Add(6 * day), start.Add(8 * day)}, windows[1])
+	assert.Equal(t, window{start.Add(4 * day), start.Add(8 * day), start.Add(8 * day), start.Add(10 * day)}, windows[2])
+
+	windows, err = splitWindows(start, start.Add(9*day), WalkForwardOptions{InSample: 4 * day, OutOfSample: 2 * day, Step: 3 * day})
+	require.NoError(t, err)
+	require.Len(t, windows, 2)
+	assert.Equal(t, start.Add(9*day), windows[1].outEnd)
+
+	_, err = splitWindows(start, start.Add(day), WalkForwardOptions{InSample: 4 * day, OutOfSample: day})
+	assert.Error(t, err)
+	_, err = splitWindows(start, start.Add(day), WalkForwardOptions{InSample: 0, OutOfSample: day})
+	assert.Error(t, err)
+
+	_, err = splitWindows(start, start.Add(10*day), WalkForwardOptions{InSample: 4 * day, OutOfSample: 2 * day, Step: day})
+	assert.ErrorContains(t, err, "out-of-sample windows would overlap")
+}
+
+func TestParamStability(t *testing.T) {
+	windows := []WalkForwardWindow{
+		{Values: []string{"4", "linear"}},
+		{Values: []string{"6", "tanh"}},
+		{Error: "failed"},
+		{Values: []string{"6", "linear"}},
+	}
+
+	s := paramStability([]string{"fast", "transform"}, windows)
+	require.Len(t, s, 2)
+
+	assert.Equal(t, []string{"4", "6", "6"}, s[0].Values)
+	assert.Equal(t, 2, s[0].Distinct)
+	assert.Equal(t, "6", s[0].Mode)
+	assert.InDelta(t, 2.0/3, s[0].ModeShare, 1e-9)
+	require.NotNil(t, s[0].Mean)
+	assert.InDelta(t, 16.0/3, *s[0].Mean, 1e-9)
+	assert.InDelta(t, 0.9428, *s[0].StdDev, 1e-4)
+	assert.Equal(t, 4.0, *s[0].Min)
+	assert.Equal(t, 6.0, *s[0].Max)
+
+	assert.Equal(t, "linear", s[1].Mode)
+	assert.Nil(t, s[1].Mean)
+}
+
+func TestOptimizer_WalkForward(t *testing.T) {
+	base := `
+strategies:
+  BTC:
+    budget: 1000
+    take_profit: 1.02
+    position_scale: 1
+    market_buffer: 10
+    indicator:
+      rsi:
+        period: 7
+        overbought: 0.7
+platform:
+  emulator:
+    data:
+      BTC: btc.csv
+    start: 2025-01-01T00:00:00Z
+    end: 2025-01-07T00:00:00Z
+    balance: 1000
+`
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	var mu sync.Mutex
+	var outOfSampleRuns []config.Emulator
+	run := func(_ context.Context, cfg config.Config) (agent.JsonReport, error) {
+		emu := cfg.PlatformRef.Platform.(config.Emulator)
+		outOfSample := emu.End.Sub(emu.Start) == day
+		assert.Equal(t, outOfSample, emu.ClosePositions)
+		if outOfSample {
+			assert.Equal(t, 7, cfg.Strategies["BTC"].Prefetch)
+		} else {
+			assert.Zero(t, cfg.Strategies["BTC"].Prefetch)
+		}
+
+		tp := cfg.Strategies["BTC"].TakeProfit
+		best := 1.01
+		if emu.Start.Sub(start) >= 3*day {
+			best = 1.03
+		}
+		sharpe := -100 * (tp - best) * (tp - best)
+
+		if outOfSample {
+			mu.Lock()
+			outOfSampleRuns = append(outOfSampleRuns, emu)
+			mu.Unlock()
+
+			return agent.JsonReport{
+				Metrics: &agent.JsonMetrics{Sharpe: &sharpe},
+				Deals: map[string][]agent.JsonDeal{
+					"BTC": {{BuyTime: emu.Start, SellTime: emu.End, Spend: "100", Gain: "10"}},
+				},
+			}, nil
+		}
+
+		return agent.JsonReport{Metrics: &agent.JsonMetrics{Sharpe: &sharpe}}, nil
+	}
+
+	o, err := NewOptimizer(slog.New(slog.NewTextHandler(io.Discard, nil)), []byte(withTestData(t, base)), Options{
+		Params: []Param{{Path: "take_profit", Values: []string{"1.01", "1.02", "1.03"}}},
+		Metric: "sharpe",
+		Dir:    t.TempDir(),
+	})
+	require.NoError(t, err)
+	o.run = run
+
+	r, err := o.WalkForward(context.Background(), WalkForwardOptions{InSample: 2 * day, OutOfSample: day})
+	require.NoError(t, err)
+
+	require.Len(t, r.WalkForward.Windows, 4)
+	require.Len(t, outOfSampleRuns, 4)
+	var chosen []string
+	for i, w := range r.WalkForward.Windows {
+		assert.Empty(t, w.Error)
+		assert.Equal(t, start.Add(time.Duration(i+2)*day), w.OutStart)
+		assert.Equal(t, start.Add(time.Duration(i+3)*day), w.OutEnd)
+		chosen = append(chosen, w.Values[0])
+	}
+	assert.Equal(t, []string{"1.01", "1.01", "1.01", "1.03"}, chosen)
+
+	assert.Equal(t, "40", r.TotalGain)
+	require.Len(t, r.Deals["BTC"], 4)
+	require.Len(t, r.WalkForward.Stability, 1)
+	assert.Equal(t, "1.01", r.WalkForward.Stability[0].Mode)
+	assert.Equal(t, 0.75, r.WalkForward.Stability[0].ModeShare)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteWalkForward(&buf, r))
+
+	var decoded map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Contains(t, decoded, "total_gain")
+	assert.Contains(t, decoded, "walk_forward")
+}
@@ -45,7 +45,7 @@ func newAlpacaPlatformWithApi(log *slog.Logger, cfg config.Alpaca, api alpacaApi
 }
 
 func NewAlpacaPlatform(log *slog.Logger, cfg config.Alpaca) (*AlpacaPlatform, error) {
-	api := newAlpacaApi(cfg.ApiKey, cfg.Secret, cfg.BaseUrl)
+	api := newAlpacaApi(cfg.ApiKey, cfg.Secret, cfg.BaseUrl, cfg.DataUrl)
 	return newAlpacaPlatformWithApi(log, cfg, api)
 }
 
@@ -12,9 +12,10 @@ type alpacaApi struct {
 	apiKey string
 	secret string
 	client *alpaca.Client
+	data   *marketdata.Client
 }
 
-func newAlpacaApi(apiKey string, secret string, baseUrl string) *alpacaApi {
+func newAlpacaApi(apiKey string, secret string, baseUrl string, dataUrl string) *alpacaApi {
 	return &alpacaApi{
 		apiKey: apiKey,
 		secret: secret,
@@ -23,11 +24,16 @@ func newAlpacaApi(apiKey string, secret string, baseUrl string) *alpacaApi {
 			APIKey:    apiKey,
 			APISecret: secret,
 		}),
+		data: marketdata.NewClient(marketdata.ClientOpts{
+			BaseURL:   dataUrl,
+			APIKey:    apiKey,
+			APISecret: secret,
+		}),
 	}
 }
 
 func (a *alpacaApi) GetCryptoBars(symbol string, req marketdata.GetCryptoBarsRequest) ([]marketdata.CryptoBar, error) {
-	return marketdata.GetCryptoBars(symbol, req)
+	return a.data.GetCryptoBars(symbol, req)
 }
 
 func (a *alpacaApi) GetCryptoBarsStream(ctx context.Context, symbol string) (<-chan stream.CryptoBar, <-chan error) {
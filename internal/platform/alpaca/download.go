@@ -0,0 +1,190 @@
+package alpaca
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+	"github.com/gamma-omg/trading-bot/internal/config"
+)
+
+const (
+	downloadPageSize = 10000
+	downloadHeader   = "timestamp,open,high,low,close,volume\n"
+	downloadTailSize = 64 * 1024
+)
+
+type DownloadRequest struct {
+	Symbol    string
+	Start     time.Time
+	End       time.Time
+	TimeFrame marketdata.TimeFrame
+	Path      string
+}
+
+type Downloader struct {
+	log      *slog.Logger
+	api      alpacaApiWrapper
+	pageSize int
+}
+
+func newDownloaderWithApi(log *slog.Logger, api alpacaApiWrapper, pageSize int) *Downloader {
+	return &Downloader{
+		log:      log,
+		api:      api,
+		pageSize: pageSize,
+	}
+}
+
+func NewDownloader(log *slog.Logger, cfg config.Alpaca) *Downloader {
+	api := newAlpacaApi(cfg.ApiKey, cfg.Secret, cfg.BaseUrl, cfg.DataUrl)
+	return newDownloaderWithApi(log, api, downloadPageSize)
+}
+
+func (d *Downloader) Download(ctx context.Context, req DownloadRequest) (int, error) {
+	f, err := os.OpenFile(req.Path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", req.Path, err)
+	}
+	defer f.Close()
+
+	last, err := resumeDownload(f)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resume %s: %w", req.Path, err)
+	}
+
+	start := req.Start
+	if !last.IsZero() && !last.Before(start) {
+		start = last.Add(time.Nanosecond)
+		d.log.Info("resume download", slog.String("symbol", req.Symbol), slog.Time("from", start))
+	}
+
+	total := 0
+	for !start.After(req.End) {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		bars, err := d.api.GetCryptoBars(req.Symbol, marketdata.GetCryptoBarsRequest{
+			CryptoFeed: marketdata.US,
+			TimeFrame:  req.TimeFrame,
+			Start:      start,
+			End:        req.End,
+			TotalLimit: d.pageSize,
+		})
+		if err != nil {
+			return total, fmt.Errorf("failed to fetch bars for %s: %w", req.Symbol, err)
+		}
+		if len(bars) == 0 {
+			break
+		}
+
+		if err := writeBars(f, bars); err != nil {
+			return total, fmt.Errorf("failed to write %s: %w", req.Path, err)
+		}
+
+		total += len(bars)
+		start = bars[len(bars)-1].Timestamp.Add(time.Nanosecond)
+		d.log.Info("downloaded bars", slog.String("symbol", req.Symbol), slog.Int("count", total), slog.Time("last", bars[len(bars)-1].Timestamp))
+
+		if len(bars) < d.pageSize {
+			break
+		}
+	}
+
+	return total, nil
+}
+
+func resumeDownload(f *os.File) (time.Time, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	size := info.Size()
+	offset := max(0, size-downloadTailSize)
+	tail := make([]byte, size-offset)
+	if _, err := f.ReadAt(tail, offset); err != nil && err != io.EOF {
+		return time.Time{}, err
+	}
+
+	// drop a line left incomplete by an interrupted download
+	end := bytes.LastIndexByte(tail, '\n') + 1
+	if end == 0 && offset > 0 {
+		return time.Time{}, fmt.Errorf("last line is longer than %d bytes", downloadTailSize)
+	}
+	if err := f.Truncate(offset + int64(end)); err != nil {
+		return time.Time{}, err
+	}
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		return time.Time{}, err
+	}
+
+	if offset+int64(end) == 0 {
+		_, err := f.WriteString(downloadHeader)
+		return time.Time{}, err
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(tail[:end])), "\n")
+	fields := strings.SplitN(lines[len(lines)-1], ",", 2)
+	ts, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		// only the header has been written so far
+		return time.Time{}, nil
+	}
+
+	return time.Unix(ts, 0), nil
+}
+
+func writeBars(f *os.File, bars []marketdata.CryptoBar) error {
+	w := bufio.NewWriter(f)
+	for _, b := range bars {
+		fmt.Fprintf(w, "%d,%s,%s,%s,%s,%s\n",
+			b.Timestamp.Unix(),
+			formatPrice(b.Open),
+			formatPrice(b.High),
+			formatPrice(b.Low),
+			formatPrice(b.Close),
+			formatPrice(b.Volume))
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
+
+func formatPrice(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func ParseTimeFrame(s string) (marketdata.TimeFrame, error) {
+	units := []marketdata.TimeFrameUnit{marketdata.Min, marketdata.Hour, marketdata.Day, marketdata.Week, marketdata.Month}
+	for _, u := range units {
+		n, ok := strings.CutSuffix(s, string(u))
+		if !ok {
+			continue
+		}
+
+		if n == "" {
+			n = "1"
+		}
+		v, err := strconv.Atoi(n)
+		if err != nil || v <= 0 {
+			break
+		}
+
+		return marketdata.NewTimeFrame(v, u), nil
+	}
+
+	return marketdata.TimeFrame{}, fmt.Errorf("invalid timeframe %q, expected e.g. 1Min, 15Min, 1Hour, 1Day", s)
+}
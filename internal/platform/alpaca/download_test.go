@@ -0,0 +1,190 @@
+package alpaca
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testBarsServer struct {
+	t        *testing.T
+	bars     []marketdata.CryptoBar
+	pageSize int
+	failAt   int32
+	requests atomic.Int32
+}
+
+func (s *testBarsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasSuffix(r.URL.Path, "/v1beta3/crypto/us/bars") {
+		http.NotFound(w, r)
+		return
+	}
+
+	if n := s.requests.Add(1); s.failAt > 0 && n >= s.failAt {
+		http.Error(w, `{"message":"unavailable"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	q := r.URL.Query()
+	symbol := q.Get("symbols")
+	start, err := time.Parse(time.RFC3339Nano, q.Get("start"))
+	require.NoError(s.t, err)
+	end, err := time.Parse(time.RFC3339Nano, q.Get("end"))
+	require.NoError(s.t, err)
+	limit, err := strconv.Atoi(q.Get("limit"))
+	require.NoError(s.t, err)
+	offset, _ := strconv.Atoi(q.Get("page_token"))
+
+	var matched []marketdata.CryptoBar
+	for _, b := range s.bars {
+		if !b.Timestamp.Before(start) && !b.Timestamp.After(end) {
+			matched = append(matched, b)
+		}
+	}
+
+	page := matched[min(offset, len(matched)):]
+	page = page[:min(len(page), limit, s.pageSize)]
+
+	var token *string
+	if next := offset + len(page); next < len(matched) {
+		t := strconv.Itoa(next)
+		token = &t
+	}
+
+	resp := map[string]any{
+		"bars":            map[string][]marketdata.CryptoBar{symbol: page},
+		"next_page_token": token,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	require.NoError(s.t, json.NewEncoder(w).Encode(resp))
+}
+
+func newTestBarsServer(t *testing.T, count int, failAt int32) (*testBarsServer, *httptest.Server) {
+	s := &testBarsServer{t: t, pageSize: 4, failAt: failAt}
+	for i := range count {
+		s.bars = append(s.bars, marketdata.CryptoBar{
+			Timestamp: time.Unix(int64(1700000000+i*60), 0).UTC(),
+			Open:      float64(100 + i),
+			High:      float64(110 + i),
+			Low:       float64(90 + i),
+			Close:     100.5 + float64(i),
+			Volume:    0.25,
+		})
+	}
+
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func newTestDownloader(url string) *Downloader {
+	api := newAlpacaApi("key", "secret", url, url)
+	return newDownloaderWithApi(slog.Default(), api, 10)
+}
+
+func expectedCsv(bars []marketdata.CryptoBar) string {
+	var sb strings.Builder
+	sb.WriteString(downloadHeader)
+	for _, b := range bars {
+		fmt.Fprintf(&sb, "%d,%v,%v,%v,%v,%v\n", b.Timestamp.Unix(), b.Open, b.High, b.Low, b.Close, b.Volume)
+	}
+
+	return sb.String()
+}
+
+func TestDownload(t *testing.T) {
+	s, srv := newTestBarsServer(t, 25, 0)
+	path := filepath.Join(t.TempDir(), "btc.csv")
+
+	n, err := newTestDownloader(srv.URL).Download(context.Background(), DownloadRequest{
+		Symbol:    "BTC/USD",
+		Start:     s.bars[2].Timestamp,
+		End:       s.bars[22].Timestamp,
+		TimeFrame: marketdata.OneMin,
+		Path:      path,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 21, n)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, expectedCsv(s.bars[2:23]), string(data))
+}
+
+func TestDownload_resume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "btc.csv")
+
+	s, failing := newTestBarsServer(t, 25, 2)
+	req := DownloadRequest{
+		Symbol:    "BTC/USD",
+		Start:     s.bars[0].Timestamp,
+		End:       s.bars[24].Timestamp,
+		TimeFrame: marketdata.OneMin,
+		Path:      path,
+	}
+
+	n, err := newTestDownloader(failing.URL).Download(context.Background(), req)
+	require.Error(t, err)
+	assert.Equal(t, 0, n)
+
+	s, failing = newTestBarsServer(t, 25, 4)
+	n, err = newTestDownloader(failing.URL).Download(context.Background(), req)
+	require.Error(t, err)
+	assert.Equal(t, 10, n)
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteString("1700000600,1")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	_, srv := newTestBarsServer(t, 25, 0)
+	n, err = newTestDownloader(srv.URL).Download(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, 15, n)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, expectedCsv(s.bars), string(data))
+
+	_, srv = newTestBarsServer(t, 30, 0)
+	req.End = time.Unix(1700000000+29*60, 0)
+	n, err = newTestDownloader(srv.URL).Download(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+}
+
+func TestParseTimeFrame(t *testing.T) {
+	tbl := map[string]marketdata.TimeFrame{
+		"1Min":  marketdata.OneMin,
+		"15Min": marketdata.NewTimeFrame(15, marketdata.Min),
+		"Hour":  marketdata.OneHour,
+		"1Day":  marketdata.OneDay,
+		"2Week": marketdata.NewTimeFrame(2, marketdata.Week),
+	}
+
+	for s, tf := range tbl {
+		res, err := ParseTimeFrame(s)
+		require.NoError(t, err)
+		assert.Equal(t, tf, res)
+	}
+
+	for _, s := range []string{"", "1Sec", "0Min", "-1Hour", "xDay"} {
+		_, err := ParseTimeFrame(s)
+		assert.Error(t, err, s)
+	}
+}
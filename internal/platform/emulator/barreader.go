@@ -6,12 +6,9 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
-	"os"
-	"strconv"
-	"time"
 
+	"github.com/gamma-omg/trading-bot/internal/config"
 	"github.com/gamma-omg/trading-bot/internal/market"
-	"github.com/shopspring/decimal"
 )
 
 type barFilter func(b market.Bar) bool
@@ -23,6 +20,7 @@ type barReadResult struct {
 
 type barReader struct {
 	rdr    *csv.Reader
+	schema config.CsvSchema
 	filter barFilter
 }
 
@@ -31,16 +29,33 @@ func newBarReader(dataPath string) (*barReader, io.Closer, error) {
 }
 
 func newBarReaderWithFilter(dataPath string, filter barFilter) (*barReader, io.Closer, error) {
-	f, err := os.Open(dataPath)
+	return newBarReaderWithSchema(dataPath, config.CsvSchema{}, filter)
+}
+
+func newBarReaderWithSchema(dataPath string, schema config.CsvSchema, filter barFilter) (*barReader, io.Closer, error) {
+	r, closer, err := openDataFile(dataPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create bar streamer: %w", err)
+	}
+
+	br := bufio.NewReader(r)
+	delim, err := sniffDelimiter(schema, br)
 	if err != nil {
+		closer.Close()
 		return nil, nil, fmt.Errorf("unable to create bar streamer: %w", err)
 	}
 
+	rdr := csv.NewReader(br)
+	rdr.Comma = delim
+	rdr.FieldsPerRecord = -1
+	rdr.ReuseRecord = true
+
 	streamer := &barReader{
-		rdr:    csv.NewReader(bufio.NewReader(f)),
+		rdr:    rdr,
+		schema: schema,
 		filter: filter,
 	}
-	return streamer, f, nil
+	return streamer, closer, nil
 }
 
 func (b *barReader) Read(ctx context.Context) <-chan barReadResult {
@@ -48,65 +63,42 @@ func (b *barReader) Read(ctx context.Context) <-chan barReadResult {
 	go func() {
 		defer close(bars)
 
-		if _, err := b.rdr.Read(); err != nil {
-			bars <- barReadResult{market.Bar{}, fmt.Errorf("failed to read csv header: %w", err)}
+		first, err := b.rdr.Read()
+		if err != nil {
+			sendBarResult(ctx, bars, barReadResult{market.Bar{}, fmt.Errorf("failed to read csv header: %w", err)})
 			return
 		}
 
-		for {
-			data, err := b.rdr.Read()
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				bars <- barReadResult{market.Bar{}, fmt.Errorf("failed to read bar data: %w", err)}
-				return
-			}
-
-			timestamp, err := strconv.ParseFloat(data[0], 64)
-			if err != nil {
-				bars <- barReadResult{market.Bar{}, fmt.Errorf("failed to parse bar time: %w", err)}
-				return
-			}
-
-			open, err := decimal.NewFromString(data[1])
-			if err != nil {
-				bars <- barReadResult{market.Bar{}, fmt.Errorf("failed to read oepn price: %w", err)}
-				return
-			}
-
-			high, err := decimal.NewFromString(data[2])
-			if err != nil {
-				bars <- barReadResult{market.Bar{}, fmt.Errorf("failed to read high price: %w", err)}
-				return
-			}
+		layout, err := newCsvLayout(b.schema, first)
+		if err != nil {
+			sendBarResult(ctx, bars, barReadResult{market.Bar{}, fmt.Errorf("failed to resolve csv schema: %w", err)})
+			return
+		}
 
-			low, err := decimal.NewFromString(data[3])
-			if err != nil {
-				bars <- barReadResult{market.Bar{}, fmt.Errorf("failed to read low price: %w", err)}
-				return
-			}
+		data := first
+		if layout.header {
+			data = nil
+		}
 
-			cloze, err := decimal.NewFromString(data[4])
-			if err != nil {
-				bars <- barReadResult{market.Bar{}, fmt.Errorf("failed to read close price: %w", err)}
-				return
+		for {
+			if data == nil {
+				data, err = b.rdr.Read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					sendBarResult(ctx, bars, barReadResult{market.Bar{}, fmt.Errorf("failed to read bar data: %w", err)})
+					return
+				}
 			}
 
-			volume, err := decimal.NewFromString(data[5])
+			bar, err := layout.parse(data)
+			data = nil
 			if err != nil {
-				bars <- barReadResult{market.Bar{}, fmt.Errorf("failed to read volume price: %w", err)}
+				sendBarResult(ctx, bars, barReadResult{market.Bar{}, err})
 				return
 			}
 
-			bar := market.Bar{
-				Time:   time.Unix(int64(timestamp), 0),
-				Open:   open,
-				Close:  cloze,
-				High:   high,
-				Low:    low,
-				Volume: volume,
-			}
 			if b.filter(bar) {
 				select {
 				case bars <- barReadResult{bar, nil}:
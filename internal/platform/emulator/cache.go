@@ -0,0 +1,554 @@
+package emulator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/gamma-omg/trading-bot/internal/market"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	barCacheVersion   uint16 = 1
+	barCacheBlockSize        = 4096
+	barCacheExt              = ".bars"
+)
+
+var (
+	barCacheMagic       = []byte("GTBARS")
+	errBarCacheOverflow = errors.New("price does not fit into cache format")
+)
+
+type barCache struct {
+	dir string
+}
+
+func newBarCache(dir string) *barCache {
+	if dir == "" {
+		return nil
+	}
+
+	return &barCache{dir: dir}
+}
+
+func (c *barCache) Read(ctx context.Context, path string, schema config.CsvSchema) <-chan barReadResult {
+	out := make(chan barReadResult)
+	go func() {
+		defer close(out)
+
+		key, err := barCacheKey(path, schema)
+		if err != nil {
+			sendBarResult(ctx, out, barReadResult{market.Bar{}, err})
+			return
+		}
+
+		if f, err := os.Open(c.path(key)); err == nil {
+			defer f.Close()
+
+			if rdr, err := newBarCacheReader(f, key); err == nil {
+				c.stream(ctx, rdr, out)
+				return
+			}
+		}
+
+		c.fill(ctx, path, schema, key, out)
+	}()
+
+	return out
+}
+
+// firstBarTime reads the first bar time from an existing cache without filling it.
+func (c *barCache) firstBarTime(path string, schema config.CsvSchema) (time.Time, bool) {
+	key, err := barCacheKey(path, schema)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	f, err := os.Open(c.path(key))
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer f.Close()
+
+	rdr, err := newBarCacheReader(f, key)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	bars, err := rdr.Next()
+	if err == io.EOF {
+		return time.Time{}, true
+	}
+	if err != nil || len(bars) == 0 {
+		return time.Time{}, false
+	}
+
+	return bars[0].Time, true
+}
+
+func (c *barCache) path(key []byte) string {
+	return filepath.Join(c.dir, hex.EncodeToString(key)+barCacheExt)
+}
+
+func (c *barCache) stream(ctx context.Context, rdr *barCacheReader, out chan<- barReadResult) {
+	for {
+		bars, err := rdr.Next()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			sendBarResult(ctx, out, barReadResult{market.Bar{}, fmt.Errorf("failed to read bar cache: %w", err)})
+			return
+		}
+
+		for _, b := range bars {
+			if !sendBarResult(ctx, out, barReadResult{b, nil}) {
+				return
+			}
+		}
+	}
+}
+
+func (c *barCache) fill(ctx context.Context, path string, schema config.CsvSchema, key []byte, out chan<- barReadResult) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w, tmp := c.create(key)
+	defer func() {
+		if tmp != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	for r := range readCsvBars(ctx, path, schema) {
+		if r.err != nil {
+			sendBarResult(ctx, out, r)
+			return
+		}
+
+		if w != nil && w.Write(r.bar) != nil {
+			w = nil
+		}
+
+		if !sendBarResult(ctx, out, r) {
+			return
+		}
+	}
+
+	if w == nil || ctx.Err() != nil || w.Close() != nil || tmp.Close() != nil {
+		return
+	}
+
+	if os.Rename(tmp.Name(), c.path(key)) == nil {
+		tmp = nil
+	}
+}
+
+func (c *barCache) create(key []byte) (*barCacheWriter, *os.File) {
+	if err := os.MkdirAll(c.dir, os.ModePerm); err != nil {
+		return nil, nil
+	}
+
+	f, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return nil, nil
+	}
+
+	w, err := newBarCacheWriter(f, key)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, nil
+	}
+
+	return w, f
+}
+
+func sendBarResult(ctx context.Context, out chan<- barReadResult, r barReadResult) bool {
+	select {
+	case out <- r:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// barCacheKey identifies a data file by its path, size and modification time, the contents are compared by
+// VerifyCache only.
+func barCacheKey(path string, schema config.CsvSchema) ([]byte, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve data file path: %w", err)
+	}
+
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat data file: %w", err)
+	}
+
+	h := sha256.New()
+	if err := binary.Write(h, binary.LittleEndian, barCacheVersion); err != nil {
+		return nil, err
+	}
+
+	h.Write([]byte(abs))
+	if err := binary.Write(h, binary.LittleEndian, []int64{info.Size(), info.ModTime().UnixNano()}); err != nil {
+		return nil, err
+	}
+
+	s, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash csv schema: %w", err)
+	}
+	h.Write(s)
+
+	return h.Sum(nil), nil
+}
+
+type barCacheWriter struct {
+	w     *bufio.Writer
+	bars  []market.Bar
+	total uint64
+}
+
+func newBarCacheWriter(w io.Writer, key []byte) (*barCacheWriter, error) {
+	cw := &barCacheWriter{
+		w:    bufio.NewWriter(w),
+		bars: make([]market.Bar, 0, barCacheBlockSize),
+	}
+
+	if err := cw.write(barCacheMagic, barCacheVersion, key); err != nil {
+		return nil, fmt.Errorf("failed to write bar cache header: %w", err)
+	}
+
+	return cw, nil
+}
+
+func (cw *barCacheWriter) Write(b market.Bar) error {
+	cw.bars = append(cw.bars, b)
+	if len(cw.bars) < barCacheBlockSize {
+		return nil
+	}
+
+	return cw.flushBlock()
+}
+
+func (cw *barCacheWriter) Close() error {
+	if err := cw.flushBlock(); err != nil {
+		return err
+	}
+
+	if err := cw.write(uint32(0), cw.total, barCacheMagic); err != nil {
+		return fmt.Errorf("failed to write bar cache footer: %w", err)
+	}
+
+	return cw.w.Flush()
+}
+
+func (cw *barCacheWriter) flushBlock() error {
+	n := len(cw.bars)
+	if n == 0 {
+		return nil
+	}
+
+	times := make([]int64, n)
+	for i, b := range cw.bars {
+		times[i] = b.Time.UnixNano()
+	}
+
+	if err := cw.write(uint32(n), times); err != nil {
+		return fmt.Errorf("failed to write bar cache block: %w", err)
+	}
+
+	coefs := make([]int64, n)
+	values := make([]decimal.Decimal, n)
+	for _, field := range barCacheColumns {
+		for i := range cw.bars {
+			values[i] = *field(&cw.bars[i])
+		}
+
+		exp, err := encodeDecimals(values, coefs)
+		if err != nil {
+			return err
+		}
+
+		if err := cw.write(exp, coefs); err != nil {
+			return fmt.Errorf("failed to write bar cache block: %w", err)
+		}
+	}
+
+	cw.total += uint64(n)
+	cw.bars = cw.bars[:0]
+	return nil
+}
+
+func (cw *barCacheWriter) write(values ...any) error {
+	for _, v := range values {
+		if err := binary.Write(cw.w, binary.LittleEndian, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var barCacheColumns = []func(b *market.Bar) *decimal.Decimal{
+	func(b *market.Bar) *decimal.Decimal { return &b.Open },
+	func(b *market.Bar) *decimal.Decimal { return &b.High },
+	func(b *market.Bar) *decimal.Decimal { return &b.Low },
+	func(b *market.Bar) *decimal.Decimal { return &b.Close },
+	func(b *market.Bar) *decimal.Decimal { return &b.Volume },
+}
+
+func encodeDecimals(values []decimal.Decimal, coefs []int64) (int32, error) {
+	exp := int32(math.MaxInt32)
+	for _, v := range values {
+		exp = min(exp, v.Exponent())
+	}
+
+	ten := big.NewInt(10)
+	for i, v := range values {
+		c := v.Coefficient()
+		if d := v.Exponent() - exp; d > 0 {
+			c.Mul(c, new(big.Int).Exp(ten, big.NewInt(int64(d)), nil))
+		}
+		if !c.IsInt64() {
+			return 0, errBarCacheOverflow
+		}
+		coefs[i] = c.Int64()
+	}
+
+	return exp, nil
+}
+
+type barCacheReader struct {
+	r     *bufio.Reader
+	total uint64
+}
+
+func newBarCacheReader(r io.Reader, key []byte) (*barCacheReader, error) {
+	cr := &barCacheReader{r: bufio.NewReader(r)}
+
+	magic := make([]byte, len(barCacheMagic))
+	var version uint16
+	stored := make([]byte, len(key))
+	if err := cr.read(magic, &version, stored); err != nil {
+		return nil, fmt.Errorf("failed to read bar cache header: %w", err)
+	}
+
+	if !bytes.Equal(magic, barCacheMagic) {
+		return nil, errors.New("not a bar cache file")
+	}
+	if version != barCacheVersion {
+		return nil, fmt.Errorf("unsupported bar cache version: %d", version)
+	}
+	if !bytes.Equal(stored, key) {
+		return nil, errors.New("bar cache does not match data file")
+	}
+
+	return cr, nil
+}
+
+func (cr *barCacheReader) Next() ([]market.Bar, error) {
+	var n uint32
+	if err := cr.read(&n); err != nil {
+		return nil, err
+	}
+
+	if n == 0 {
+		return nil, cr.readFooter()
+	}
+	if n > barCacheBlockSize {
+		return nil, fmt.Errorf("invalid bar cache block size: %d", n)
+	}
+
+	times := make([]int64, n)
+	if err := cr.read(times); err != nil {
+		return nil, err
+	}
+
+	bars := make([]market.Bar, n)
+	for i, t := range times {
+		bars[i].Time = time.Unix(0, t)
+	}
+
+	coefs := make([]int64, n)
+	for _, field := range barCacheColumns {
+		var exp int32
+		if err := cr.read(&exp, coefs); err != nil {
+			return nil, err
+		}
+
+		for i, c := range coefs {
+			*field(&bars[i]) = decimal.New(c, exp)
+		}
+	}
+
+	cr.total += uint64(n)
+	return bars, nil
+}
+
+func (cr *barCacheReader) readFooter() error {
+	var total uint64
+	magic := make([]byte, len(barCacheMagic))
+	if err := cr.read(&total, magic); err != nil {
+		return fmt.Errorf("failed to read bar cache footer: %w", err)
+	}
+
+	if !bytes.Equal(magic, barCacheMagic) || total != cr.total {
+		return errors.New("bar cache is truncated or corrupted")
+	}
+
+	return io.EOF
+}
+
+func (cr *barCacheReader) read(values ...any) error {
+	for _, v := range values {
+		if err := binary.Read(cr.r, binary.LittleEndian, v); err != nil {
+			if err == io.EOF {
+				return io.ErrUnexpectedEOF
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+type CacheStatus struct {
+	Symbol string
+	File   string
+	Cache  string
+	Bars   int
+	Err    error
+}
+
+func BuildCache(ctx context.Context, cfg config.Emulator) ([]CacheStatus, error) {
+	return forEachCachedFile(cfg, func(c *barCache, s *CacheStatus, schema config.CsvSchema) {
+		for r := range c.Read(ctx, s.File, schema) {
+			if r.err != nil {
+				s.Err = r.err
+				return
+			}
+			s.Bars++
+		}
+	})
+}
+
+func VerifyCache(ctx context.Context, cfg config.Emulator) ([]CacheStatus, error) {
+	return forEachCachedFile(cfg, func(c *barCache, s *CacheStatus, schema config.CsvSchema) {
+		s.Bars, s.Err = verifyCacheFile(ctx, s.Cache, s.File, schema)
+	})
+}
+
+func forEachCachedFile(cfg config.Emulator, fn func(c *barCache, s *CacheStatus, schema config.CsvSchema)) ([]CacheStatus, error) {
+	c := newBarCache(cfg.CacheDir)
+	if c == nil {
+		return nil, errors.New("cache_dir is not configured")
+	}
+
+	var res []CacheStatus
+	for _, symbol := range slices.Sorted(maps.Keys(cfg.Data)) {
+		files, err := expandDataPath(cfg.Data[symbol])
+		if err != nil {
+			return nil, fmt.Errorf("failed to list data files for %s: %w", symbol, err)
+		}
+
+		schema := cfg.SchemaFor(symbol)
+		for _, f := range files {
+			s := CacheStatus{Symbol: symbol, File: f}
+			key, err := barCacheKey(f, schema)
+			if err != nil {
+				s.Err = err
+			} else {
+				s.Cache = c.path(key)
+				fn(c, &s, schema)
+			}
+			res = append(res, s)
+		}
+	}
+
+	return res, nil
+}
+
+func verifyCacheFile(ctx context.Context, cachePath, dataPath string, schema config.CsvSchema) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	f, err := os.Open(cachePath)
+	if err != nil {
+		return 0, fmt.Errorf("cache is missing: %w", err)
+	}
+	defer f.Close()
+
+	key, err := barCacheKey(dataPath, schema)
+	if err != nil {
+		return 0, err
+	}
+
+	rdr, err := newBarCacheReader(f, key)
+	if err != nil {
+		return 0, err
+	}
+
+	src := readCsvBars(ctx, dataPath, schema)
+	n := 0
+	for {
+		cached, err := rdr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return n, err
+		}
+
+		for _, b := range cached {
+			r, ok := <-src
+			if !ok {
+				return n, errors.New("cache has more bars than data file")
+			}
+			if r.err != nil {
+				return n, r.err
+			}
+			if !sameBar(b, r.bar) {
+				return n, fmt.Errorf("cached bar %d does not match data file", n)
+			}
+			n++
+		}
+	}
+
+	if r, ok := <-src; ok {
+		if r.err != nil {
+			return n, r.err
+		}
+		return n, errors.New("cache has fewer bars than data file")
+	}
+
+	return n, nil
+}
+
+func sameBar(a, b market.Bar) bool {
+	return a.Time.Equal(b.Time) &&
+		a.Open.Equal(b.Open) &&
+		a.High.Equal(b.High) &&
+		a.Low.Equal(b.Low) &&
+		a.Close.Equal(b.Close) &&
+		a.Volume.Equal(b.Volume)
+}
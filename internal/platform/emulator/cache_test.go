@@ -0,0 +1,201 @@
+package emulator
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/gamma-omg/trading-bot/internal/market"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readCache(t *testing.T, c *barCache, path string) []market.Bar {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var bars []market.Bar
+	for r := range c.Read(ctx, path, config.CsvSchema{}) {
+		require.NoError(t, r.err)
+		bars = append(bars, r.bar)
+	}
+
+	return bars
+}
+
+func cacheFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"+barCacheExt))
+	require.NoError(t, err)
+	return files
+}
+
+func TestBarCache_roundTrip(t *testing.T) {
+	key := []byte("key")
+	bars := make([]market.Bar, barCacheBlockSize+10)
+	for i := range bars {
+		bars[i] = market.Bar{
+			Time:   time.Unix(int64(i*60), 0),
+			Open:   decimal.RequireFromString("101.5"),
+			High:   decimal.New(int64(110+i), 0),
+			Low:    decimal.RequireFromString("0.00012345"),
+			Close:  decimal.New(int64(i), 3),
+			Volume: decimal.RequireFromString("-3.25"),
+		}
+	}
+
+	var buf bytes.Buffer
+	w, err := newBarCacheWriter(&buf, key)
+	require.NoError(t, err)
+	for _, b := range bars {
+		require.NoError(t, w.Write(b))
+	}
+	require.NoError(t, w.Close())
+
+	r, err := newBarCacheReader(bytes.NewReader(buf.Bytes()), key)
+	require.NoError(t, err)
+
+	var read []market.Bar
+	for {
+		block, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		read = append(read, block...)
+	}
+
+	require.Len(t, read, len(bars))
+	for i := range bars {
+		assert.True(t, sameBar(bars[i], read[i]), "bar %d differs", i)
+	}
+}
+
+func TestBarCache_corrupted(t *testing.T) {
+	key := []byte("key")
+
+	var buf bytes.Buffer
+	w, err := newBarCacheWriter(&buf, key)
+	require.NoError(t, err)
+	require.NoError(t, w.Write(market.Bar{Time: time.Unix(60, 0), Open: decimal.NewFromInt(1)}))
+	require.NoError(t, w.Close())
+	data := buf.Bytes()
+
+	_, err = newBarCacheReader(bytes.NewReader(data), []byte("other"))
+	assert.Error(t, err)
+
+	r, err := newBarCacheReader(bytes.NewReader(data[:len(data)-4]), key)
+	require.NoError(t, err)
+	_, err = r.Next()
+	require.NoError(t, err)
+	_, err = r.Next()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestBarCache_fillAndReuse(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, t.TempDir(), "bars.csv", []byte(janBars))
+	c := newBarCache(dir)
+
+	first := readCache(t, c, path)
+	require.Len(t, first, 3)
+	files := cacheFiles(t, dir)
+	require.Len(t, files, 1)
+
+	info, err := os.Stat(files[0])
+	require.NoError(t, err)
+
+	second := readCache(t, c, path)
+	require.Len(t, second, 3)
+	for i := range first {
+		assert.True(t, sameBar(first[i], second[i]))
+	}
+
+	again, err := os.Stat(files[0])
+	require.NoError(t, err)
+	assert.Equal(t, info.ModTime(), again.ModTime())
+
+	require.NoError(t, os.WriteFile(path, []byte(febBars), 0o644))
+	assert.Len(t, readCache(t, c, path), 2)
+	assert.Len(t, cacheFiles(t, dir), 2)
+}
+
+func TestBarCache_firstBarTime(t *testing.T) {
+	dir := t.TempDir()
+	data := t.TempDir()
+	writeFile(t, data, "02.csv", []byte(febBars))
+	jan := writeFile(t, data, "01.csv", []byte(janBars))
+	c := newBarCache(dir)
+
+	_, ok := c.firstBarTime(jan, config.CsvSchema{})
+	assert.False(t, ok)
+
+	src, err := newBarSource(data, config.CsvSchema{}, "", c, func(market.Bar) bool { return true })
+	require.NoError(t, err)
+	files, err := src.sortFiles(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, jan, files[0])
+	assert.Empty(t, cacheFiles(t, dir))
+
+	readCache(t, c, jan)
+	first, ok := c.firstBarTime(jan, config.CsvSchema{})
+	require.True(t, ok)
+	assert.Equal(t, time.Unix(60, 0), first)
+}
+
+func TestBuildVerifyCache(t *testing.T) {
+	data := t.TempDir()
+	writeFile(t, data, "01.csv", []byte(janBars))
+	writeFile(t, data, "02.csv.gz", gzipData(t, febBars))
+
+	cfg := config.Emulator{
+		Data:     map[string]string{"BTC": data},
+		CacheDir: t.TempDir(),
+	}
+
+	ctx := context.Background()
+	res, err := VerifyCache(ctx, cfg)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Error(t, res[0].Err)
+
+	res, err = BuildCache(ctx, cfg)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.NoError(t, res[0].Err)
+	assert.Equal(t, 3, res[0].Bars)
+	assert.Equal(t, 2, res[1].Bars)
+
+	res, err = VerifyCache(ctx, cfg)
+	require.NoError(t, err)
+	for _, s := range res {
+		assert.NoError(t, s.Err)
+	}
+
+	require.NoError(t, os.WriteFile(res[0].Cache, []byte("garbage"), 0o644))
+	res, err = VerifyCache(ctx, cfg)
+	require.NoError(t, err)
+	assert.Error(t, res[0].Err)
+
+	_, err = BuildCache(ctx, config.Emulator{Data: cfg.Data})
+	assert.Error(t, err)
+}
+
+func TestEncodeDecimals_overflow(t *testing.T) {
+	values := []decimal.Decimal{
+		decimal.RequireFromString("1e15"),
+		decimal.RequireFromString("1e-10"),
+	}
+
+	_, err := encodeDecimals(values, make([]int64, len(values)))
+	assert.ErrorIs(t, err, errBarCacheOverflow)
+}
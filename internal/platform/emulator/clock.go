@@ -0,0 +1,105 @@
+package emulator
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Clock struct {
+	mu      sync.Mutex
+	active  map[string]struct{}
+	pending map[string]time.Time
+	running string
+	changed chan struct{}
+}
+
+func NewClock(symbols []string) *Clock {
+	active := make(map[string]struct{}, len(symbols))
+	for _, s := range symbols {
+		active[s] = struct{}{}
+	}
+
+	return &Clock{
+		active:  active,
+		pending: make(map[string]time.Time),
+		changed: make(chan struct{}),
+	}
+}
+
+func (c *Clock) Wait(ctx context.Context, symbol string, t time.Time) error {
+	c.mu.Lock()
+	if _, ok := c.active[symbol]; !ok {
+		c.mu.Unlock()
+		return nil
+	}
+
+	c.pending[symbol] = t
+	c.notify()
+
+	for {
+		if c.canRun(symbol) {
+			delete(c.pending, symbol)
+			c.running = symbol
+			c.mu.Unlock()
+			return nil
+		}
+
+		changed := c.changed
+		c.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			c.mu.Lock()
+			delete(c.pending, symbol)
+			c.notify()
+			c.mu.Unlock()
+			return ctx.Err()
+		case <-changed:
+		}
+
+		c.mu.Lock()
+	}
+}
+
+func (c *Clock) Done(symbol string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running == symbol {
+		c.running = ""
+		c.notify()
+	}
+}
+
+func (c *Clock) Leave(symbol string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.active, symbol)
+	delete(c.pending, symbol)
+	if c.running == symbol {
+		c.running = ""
+	}
+	c.notify()
+}
+
+func (c *Clock) canRun(symbol string) bool {
+	if c.running != "" || len(c.pending) < len(c.active) {
+		return false
+	}
+
+	t := c.pending[symbol]
+	for s, other := range c.pending {
+		if other.Before(t) || other.Equal(t) && s < symbol {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *Clock) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
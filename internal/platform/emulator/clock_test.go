@@ -0,0 +1,97 @@
+package emulator
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type clockEvent struct {
+	symbol string
+	time   time.Time
+}
+
+func TestClock_globalOrder(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	streams := map[string][]int64{
+		"BTC": {1, 4, 5, 9},
+		"ETH": {2, 3, 6, 7, 8},
+		"SOL": {3, 10},
+	}
+
+	c := NewClock([]string{"BTC", "ETH", "SOL"})
+
+	var mu sync.Mutex
+	var events []clockEvent
+	var wg sync.WaitGroup
+	for symbol, times := range streams {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer c.Leave(symbol)
+
+			for _, ts := range times {
+				bt := time.Unix(ts, 0)
+				if err := c.Wait(ctx, symbol, bt); err != nil {
+					return
+				}
+
+				mu.Lock()
+				events = append(events, clockEvent{symbol, bt})
+				mu.Unlock()
+
+				c.Done(symbol)
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.NoError(t, ctx.Err())
+	expected := []clockEvent{
+		{"BTC", time.Unix(1, 0)},
+		{"ETH", time.Unix(2, 0)},
+		{"ETH", time.Unix(3, 0)},
+		{"SOL", time.Unix(3, 0)},
+		{"BTC", time.Unix(4, 0)},
+		{"BTC", time.Unix(5, 0)},
+		{"ETH", time.Unix(6, 0)},
+		{"ETH", time.Unix(7, 0)},
+		{"ETH", time.Unix(8, 0)},
+		{"BTC", time.Unix(9, 0)},
+		{"SOL", time.Unix(10, 0)},
+	}
+	assert.Equal(t, expected, events)
+}
+
+func TestClock_unknownSymbolIsNotBlocked(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	c := NewClock([]string{"BTC"})
+	require.NoError(t, c.Wait(ctx, "ETH", time.Unix(1, 0)))
+}
+
+func TestClock_cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c := NewClock([]string{"BTC", "ETH"})
+
+	done := make(chan error)
+	go func() {
+		done <- c.Wait(ctx, "BTC", time.Unix(1, 0))
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("wait was not cancelled")
+	}
+}
@@ -2,9 +2,11 @@ package emulator
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"sync"
 
 	"github.com/gamma-omg/trading-bot/internal/config"
 	"github.com/gamma-omg/trading-bot/internal/market"
@@ -12,67 +14,178 @@ import (
 )
 
 type TradingEmulator struct {
-	cfg    config.Emulator
-	Acc    *defaultAccount
-	PosMan positionManager
+	log          *slog.Logger
+	cfg          config.Emulator
+	Acc          *defaultAccount
+	PosMan       positionManager
+	cache        *barCache
+	pacer        *replayPacer
+	controls     io.Reader
+	controlsOnce sync.Once
 }
 
 func NewTradingEmulator(log *slog.Logger, cfg config.Emulator) (*TradingEmulator, error) {
+	switch cfg.Overlap {
+	case "", overlapDedupe, overlapReject:
+	default:
+		return nil, fmt.Errorf("unknown overlap policy: %s", cfg.Overlap)
+	}
+
 	commission := newFixedRateCommission(cfg.BuyCommission, cfg.SellCommission)
 	acc := &defaultAccount{balance: decimal.NewFromInt(int64(cfg.Balance))}
 
 	emu := &TradingEmulator{
+		log:    log,
 		cfg:    cfg,
 		Acc:    acc,
 		PosMan: *newPositionManager(log, commission, acc),
+		cache:  newBarCache(cfg.CacheDir),
+	}
+
+	if cfg.Replay != nil {
+		emu.pacer = newReplayPacer(float64(cfg.Replay.Speed), cfg.Replay.Paused)
+		if cfg.Replay.Controls {
+			emu.controls = os.Stdin
+		}
 	}
 
 	return emu, nil
 }
 
-func (e *TradingEmulator) Prefetch(_ string, _ int) (<-chan market.Bar, error) {
-	return nil, errors.New("operation not supported")
+func (e *TradingEmulator) Prefetch(symbol string, count int) (<-chan market.Bar, error) {
+	path, ok := e.cfg.Data[symbol]
+	if !ok {
+		return nil, fmt.Errorf("no data file for symbol %s", symbol)
+	}
+
+	src, err := newBarSource(path, e.cfg.SchemaFor(symbol), e.cfg.Overlap, e.cache, func(market.Bar) bool {
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bars reader: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var prefetched []market.Bar
+	for r := range src.Read(ctx) {
+		if r.err != nil {
+			return nil, r.err
+		}
+		if r.bar.Time.After(e.cfg.Start) {
+			break
+		}
+
+		prefetched = append(prefetched, r.bar)
+		if len(prefetched) > count {
+			prefetched = prefetched[1:]
+		}
+	}
+
+	bars := make(chan market.Bar, len(prefetched))
+	for _, b := range prefetched {
+		bars <- b
+	}
+	close(bars)
+
+	return bars, nil
 }
 
 func (e *TradingEmulator) GetBars(ctx context.Context, symbol string) (<-chan market.Bar, <-chan error) {
 	bars := make(chan market.Bar, 64)
 	errs := make(chan error, 1)
+	e.startReplayControls(ctx)
 	go func() {
 		defer close(bars)
 		defer close(errs)
 
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
 		path, ok := e.cfg.Data[symbol]
 		if !ok {
 			errs <- fmt.Errorf("no data file for symbol %s", symbol)
 			return
 		}
 
-		rdr, closer, err := newBarReaderWithFilter(path, func(b market.Bar) bool {
+		src, err := newBarSource(path, e.cfg.SchemaFor(symbol), e.cfg.Overlap, e.cache, func(b market.Bar) bool {
 			return b.Time.After(e.cfg.Start) && b.Time.Before(e.cfg.End)
 		})
 		if err != nil {
 			errs <- fmt.Errorf("failed to create bars reader: %w", err)
 			return
 		}
-		defer func() {
-			if err := closer.Close(); err != nil {
-				errs <- fmt.Errorf("failed to close bar reader: %w", err)
-			}
-		}()
 
-		for r := range rdr.Read(ctx) {
+		var checker *barChecker
+		if e.cfg.Quality.Strict {
+			checker = newBarChecker(symbol, e.cfg.Quality)
+		}
+
+		for r := range src.Read(ctx) {
 			if r.err != nil {
 				errs <- r.err
 				continue
 			}
 
+			if checker != nil {
+				if issues := checker.Check(r.bar); len(issues) > 0 {
+					errs <- fmt.Errorf("data quality check failed: %w", issues[0])
+					return
+				}
+			}
+
+			if e.pacer != nil {
+				if err := e.pacer.Wait(ctx, r.bar.Time); err != nil {
+					return
+				}
+			}
+
 			bars <- r.bar
 		}
+
+		if checker != nil && ctx.Err() == nil {
+			if issues := checker.Flush(); len(issues) > 0 {
+				errs <- fmt.Errorf("data quality check failed: %w", issues[0])
+			}
+		}
 	}()
 
 	return bars, errs
 }
 
+func (e *TradingEmulator) Pause() {
+	if e.pacer != nil {
+		e.pacer.Pause()
+	}
+}
+
+func (e *TradingEmulator) Resume() {
+	if e.pacer != nil {
+		e.pacer.Resume()
+	}
+}
+
+func (e *TradingEmulator) Step(n int) {
+	if e.pacer != nil {
+		e.pacer.Step(n)
+	}
+}
+
+func (e *TradingEmulator) startReplayControls(ctx context.Context) {
+	if e.pacer == nil || e.controls == nil {
+		return
+	}
+
+	e.controlsOnce.Do(func() {
+		go func() {
+			if err := readReplayControls(ctx, e.log, e.controls, e.pacer); err != nil {
+				e.log.Error("failed to read replay controls", slog.Any("error", err))
+			}
+		}()
+	})
+}
+
 func (e *TradingEmulator) Open(ctx context.Context, asset *market.Asset, size decimal.Decimal) (*market.Position, error) {
 	return e.PosMan.Open(ctx, asset, size)
 }
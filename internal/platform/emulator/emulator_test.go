@@ -53,3 +53,77 @@ func TestGetBars(t *testing.T) {
 	assert.Len(t, errs, 0)
 	assert.Equal(t, 6, len(bars))
 }
+
+func TestPrefetch(t *testing.T) {
+	f := writeCsv(t, "data", `timestamp,open,high,low,close,volume
+60,1,1,1,1,1
+120,2,2,2,2,1
+180,3,3,3,3,1
+240,4,4,4,4,1
+300,5,5,5,5,1`)
+
+	emu, err := NewTradingEmulator(slog.New(slog.DiscardHandler), config.Emulator{
+		Data:  map[string]string{"BTC": f},
+		Start: time.Unix(180, 0),
+		End:   time.Unix(600, 0),
+	})
+	require.NoError(t, err)
+
+	tbl := []struct {
+		count int
+		times []int64
+	}{
+		{count: 2, times: []int64{120, 180}},
+		{count: 10, times: []int64{60, 120, 180}},
+	}
+
+	for _, c := range tbl {
+		barsCh, err := emu.Prefetch("BTC", c.count)
+		require.NoError(t, err)
+
+		var times []int64
+		for b := range barsCh {
+			times = append(times, b.Time.Unix())
+		}
+		assert.Equal(t, c.times, times)
+	}
+
+	_, err = emu.Prefetch("ETH", 1)
+	assert.Error(t, err)
+}
+
+func TestGetBars_replayStep(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	f := writeCsv(t, "data", `timestamp,open,high,low,close,volume
+1758127500.0,115510,115510,115482,115493,1.05828858
+1758127560.0,116570,116577,116569,116574,1.60268598
+1758127620.0,116570,116577,116569,116574,1.60268598`)
+
+	l := slog.New(slog.DiscardHandler)
+	emu, err := NewTradingEmulator(l, config.Emulator{
+		Data:   map[string]string{"BTC": f},
+		Start:  time.Unix(0, 0),
+		End:    time.Unix(0xfffffffffffffff, 0),
+		Replay: &config.Replay{Speed: 1, Paused: true},
+	})
+	require.NoError(t, err)
+
+	barsCh, _ := emu.GetBars(ctx, "BTC")
+	emu.Step(2)
+
+	for range 2 {
+		select {
+		case <-barsCh:
+		case <-ctx.Done():
+			t.Fatal("stepped bar was not delivered")
+		}
+	}
+
+	select {
+	case <-barsCh:
+		t.Fatal("bar delivered while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,196 @@
+package emulator
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/gamma-omg/trading-bot/internal/market"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	defaultMaxReturn = 0.25
+	intervalSamples  = 10
+)
+
+type IssueKind string
+
+const (
+	IssueGap         IssueKind = "gap"
+	IssueDuplicate   IssueKind = "duplicate"
+	IssueOutOfOrder  IssueKind = "out_of_order"
+	IssueHighLow     IssueKind = "high_below_low"
+	IssueCloseRange  IssueKind = "close_out_of_range"
+	IssueNonPositive IssueKind = "non_positive_price"
+	IssueSpike       IssueKind = "return_spike"
+)
+
+type QualityIssue struct {
+	Symbol  string
+	Kind    IssueKind
+	Time    time.Time
+	Message string
+}
+
+func (i QualityIssue) Error() string {
+	return fmt.Sprintf("%s %s at %s: %s", i.Symbol, i.Kind, i.Time.UTC().Format(time.RFC3339), i.Message)
+}
+
+type barStep struct {
+	from time.Time
+	to   time.Time
+}
+
+type barChecker struct {
+	symbol    string
+	interval  time.Duration
+	maxReturn decimal.Decimal
+	prev      *market.Bar
+	samples   []barStep
+}
+
+func newBarChecker(symbol string, cfg config.DataQuality) *barChecker {
+	maxReturn := cfg.MaxReturn
+	if maxReturn <= 0 {
+		maxReturn = defaultMaxReturn
+	}
+
+	return &barChecker{
+		symbol:    symbol,
+		interval:  cfg.Interval,
+		maxReturn: decimal.NewFromFloat(maxReturn),
+	}
+}
+
+func (c *barChecker) Check(b market.Bar) []QualityIssue {
+	var issues []QualityIssue
+	report := func(kind IssueKind, format string, args ...any) {
+		issues = append(issues, QualityIssue{
+			Symbol:  c.symbol,
+			Kind:    kind,
+			Time:    b.Time,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, p := range []decimal.Decimal{b.Open, b.High, b.Low, b.Close} {
+		if !p.IsPositive() {
+			report(IssueNonPositive, "price %s is not positive", p)
+			break
+		}
+	}
+
+	if b.High.LessThan(b.Low) {
+		report(IssueHighLow, "high %s is below low %s", b.High, b.Low)
+	} else if b.Close.LessThan(b.Low) || b.Close.GreaterThan(b.High) {
+		report(IssueCloseRange, "close %s is outside [%s, %s]", b.Close, b.Low, b.High)
+	}
+
+	if c.prev == nil {
+		c.prev = &b
+		return issues
+	}
+
+	delta := b.Time.Sub(c.prev.Time)
+	switch {
+	case delta == 0:
+		report(IssueDuplicate, "timestamp repeats the previous bar")
+		return issues
+	case delta < 0:
+		report(IssueOutOfOrder, "timestamp is %s before the previous bar", -delta)
+		return issues
+	}
+
+	issues = append(issues, c.gaps(barStep{from: c.prev.Time, to: b.Time})...)
+
+	if c.prev.Close.IsPositive() && b.Close.IsPositive() {
+		ret := b.Close.Sub(c.prev.Close).Div(c.prev.Close).Abs()
+		if ret.GreaterThan(c.maxReturn) {
+			report(IssueSpike, "close changed by %s%% from %s to %s", ret.Shift(2).Round(2), c.prev.Close, b.Close)
+		}
+	}
+
+	c.prev = &b
+	return issues
+}
+
+// Flush infers the interval from the steps seen so far when the data ended before
+// intervalSamples steps and reports their gaps.
+func (c *barChecker) Flush() []QualityIssue {
+	if len(c.samples) == 0 {
+		return nil
+	}
+
+	counts := map[time.Duration]int{}
+	for _, s := range c.samples {
+		delta := s.to.Sub(s.from)
+		counts[delta]++
+		if n := counts[delta]; n > counts[c.interval] || (n == counts[c.interval] && delta < c.interval) {
+			c.interval = delta
+		}
+	}
+
+	var issues []QualityIssue
+	for _, s := range c.samples {
+		issues = append(issues, c.gaps(s)...)
+	}
+	c.samples = nil
+	return issues
+}
+
+func (c *barChecker) gaps(s barStep) []QualityIssue {
+	if c.interval == 0 {
+		// without a configured interval the most common of the first steps is used
+		c.samples = append(c.samples, s)
+		if len(c.samples) < intervalSamples {
+			return nil
+		}
+		return c.Flush()
+	}
+
+	delta := s.to.Sub(s.from)
+	if delta <= c.interval {
+		return nil
+	}
+
+	return []QualityIssue{{
+		Symbol:  c.symbol,
+		Kind:    IssueGap,
+		Time:    s.to,
+		Message: fmt.Sprintf("%d missing bars since %s", int64(delta/c.interval)-1, s.from.UTC().Format(time.RFC3339)),
+	}}
+}
+
+func CheckData(ctx context.Context, cfg config.Emulator) ([]QualityIssue, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var issues []QualityIssue
+	for _, symbol := range slices.Sorted(maps.Keys(cfg.Data)) {
+		src, err := newBarSource(cfg.Data[symbol], cfg.SchemaFor(symbol), cfg.Overlap, newBarCache(cfg.CacheDir), func(market.Bar) bool {
+			return true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create bars reader for %s: %w", symbol, err)
+		}
+
+		c := newBarChecker(symbol, cfg.Quality)
+		for r := range src.Read(ctx) {
+			if r.err != nil {
+				return issues, fmt.Errorf("failed to read bars for %s: %w", symbol, r.err)
+			}
+			issues = append(issues, c.Check(r.bar)...)
+		}
+		issues = append(issues, c.Flush()...)
+
+		if err := ctx.Err(); err != nil {
+			return issues, err
+		}
+	}
+
+	return issues, nil
+}
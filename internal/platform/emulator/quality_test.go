@@ -0,0 +1,161 @@
+package emulator
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/gamma-omg/trading-bot/internal/market"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func qualityBar(ts int64, open, high, low, close float64) market.Bar {
+	return market.Bar{
+		Time:  time.Unix(ts, 0),
+		Open:  decimal.NewFromFloat(open),
+		High:  decimal.NewFromFloat(high),
+		Low:   decimal.NewFromFloat(low),
+		Close: decimal.NewFromFloat(close),
+	}
+}
+
+func minuteBars(minutes ...int64) []market.Bar {
+	bars := make([]market.Bar, len(minutes))
+	for i, m := range minutes {
+		bars[i] = qualityBar(60*(m+1), 10, 11, 9, 10)
+	}
+	return bars
+}
+
+func checkBars(cfg config.DataQuality, bars ...market.Bar) []IssueKind {
+	c := newBarChecker("BTC", cfg)
+
+	var kinds []IssueKind
+	for _, b := range bars {
+		for _, i := range c.Check(b) {
+			kinds = append(kinds, i.Kind)
+		}
+	}
+	for _, i := range c.Flush() {
+		kinds = append(kinds, i.Kind)
+	}
+
+	return kinds
+}
+
+func TestBarChecker(t *testing.T) {
+	tbl := []struct {
+		name string
+		cfg  config.DataQuality
+		bars []market.Bar
+		want []IssueKind
+	}{
+		{
+			name: "clean",
+			bars: []market.Bar{qualityBar(60, 10, 11, 9, 10), qualityBar(120, 10, 11, 9, 10.5), qualityBar(180, 10, 11, 9, 10)},
+		},
+		{
+			name: "gap with detected interval",
+			bars: []market.Bar{qualityBar(60, 10, 11, 9, 10), qualityBar(120, 10, 11, 9, 10), qualityBar(300, 10, 11, 9, 10)},
+			want: []IssueKind{IssueGap},
+		},
+		{
+			name: "leading gap with detected interval",
+			bars: minuteBars(0, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 14),
+			want: []IssueKind{IssueGap, IssueGap},
+		},
+		{
+			name: "sparse steps with detected interval",
+			bars: minuteBars(0, 2, 4, 5, 7, 9, 11, 13, 15, 17, 19, 20, 22),
+		},
+		{
+			name: "gap with configured interval",
+			cfg:  config.DataQuality{Interval: time.Minute},
+			bars: []market.Bar{qualityBar(60, 10, 11, 9, 10), qualityBar(180, 10, 11, 9, 10)},
+			want: []IssueKind{IssueGap},
+		},
+		{
+			name: "duplicate and out of order",
+			bars: []market.Bar{qualityBar(120, 10, 11, 9, 10), qualityBar(120, 10, 11, 9, 10), qualityBar(60, 10, 11, 9, 10)},
+			want: []IssueKind{IssueDuplicate, IssueOutOfOrder},
+		},
+		{
+			name: "invalid ranges",
+			bars: []market.Bar{qualityBar(60, 10, 9, 11, 10), qualityBar(120, 10, 11, 9, 12)},
+			want: []IssueKind{IssueHighLow, IssueCloseRange},
+		},
+		{
+			name: "non positive",
+			bars: []market.Bar{qualityBar(60, 0, 11, 0, 10)},
+			want: []IssueKind{IssueNonPositive},
+		},
+		{
+			name: "spike",
+			cfg:  config.DataQuality{MaxReturn: 0.1},
+			bars: []market.Bar{qualityBar(60, 10, 11, 9, 10), qualityBar(120, 10, 11.5, 9, 11.5), qualityBar(180, 10, 11.5, 9, 11)},
+			want: []IssueKind{IssueSpike},
+		},
+	}
+
+	for _, c := range tbl {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.want, checkBars(c.cfg, c.bars...))
+		})
+	}
+}
+
+func TestCheckData(t *testing.T) {
+	f := writeCsv(t, "data", `timestamp,open,high,low,close,volume
+60,10,11,9,10,1
+120,10,11,9,10,1
+120,10,11,9,10,1
+300,10,9,11,10,1`)
+
+	issues, err := CheckData(context.Background(), config.Emulator{Data: map[string]string{"BTC": f}})
+	require.NoError(t, err)
+	require.Len(t, issues, 3)
+	assert.Equal(t, IssueDuplicate, issues[0].Kind)
+	assert.Equal(t, IssueHighLow, issues[1].Kind)
+	assert.Equal(t, IssueGap, issues[2].Kind)
+	assert.Equal(t, "BTC", issues[2].Symbol)
+}
+
+func TestGetBars_strict(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	f := writeCsv(t, "data", `timestamp,open,high,low,close,volume
+60,10,11,9,10,1
+120,10,11,9,10,1
+180,10,11,9,12,1
+240,10,11,9,10,1`)
+
+	for _, strict := range []bool{false, true} {
+		emu, err := NewTradingEmulator(slog.New(slog.DiscardHandler), config.Emulator{
+			Data:    map[string]string{"BTC": f},
+			Start:   time.Unix(0, 0),
+			End:     time.Unix(1000, 0),
+			Quality: config.DataQuality{Strict: strict},
+		})
+		require.NoError(t, err)
+
+		barsCh, errCh := emu.GetBars(ctx, "BTC")
+		n := 0
+		for range barsCh {
+			n++
+		}
+		err = <-errCh
+
+		if strict {
+			assert.Equal(t, 2, n)
+			assert.ErrorContains(t, err, string(IssueCloseRange))
+		} else {
+			assert.Equal(t, 4, n)
+			assert.NoError(t, err)
+		}
+	}
+}
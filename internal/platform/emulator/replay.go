@@ -0,0 +1,167 @@
+package emulator
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type replayPacer struct {
+	speed   float64
+	mu      sync.Mutex
+	paused  bool
+	steps   int
+	synced  bool
+	simRef  time.Time
+	wallRef time.Time
+	changed chan struct{}
+}
+
+func newReplayPacer(speed float64, paused bool) *replayPacer {
+	return &replayPacer{
+		speed:   speed,
+		paused:  paused,
+		changed: make(chan struct{}),
+	}
+}
+
+func (p *replayPacer) Wait(ctx context.Context, t time.Time) error {
+	p.mu.Lock()
+	for {
+		var timer *time.Timer
+		if p.paused {
+			if p.steps > 0 {
+				p.steps--
+				p.sync(t)
+				p.mu.Unlock()
+				return nil
+			}
+		} else {
+			if !p.synced {
+				p.sync(t)
+			}
+
+			delay := p.delay(t)
+			if delay <= 0 {
+				p.mu.Unlock()
+				return nil
+			}
+			timer = time.NewTimer(delay)
+		}
+
+		changed := p.changed
+		p.mu.Unlock()
+
+		var expired <-chan time.Time
+		if timer != nil {
+			expired = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return ctx.Err()
+		case <-changed:
+			if timer != nil {
+				timer.Stop()
+			}
+		case <-expired:
+		}
+
+		p.mu.Lock()
+	}
+}
+
+func (p *replayPacer) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.paused = true
+	p.notify()
+}
+
+func (p *replayPacer) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.paused = false
+	p.synced = false
+	p.notify()
+}
+
+func (p *replayPacer) Step(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.steps += n
+	p.notify()
+}
+
+func (p *replayPacer) sync(t time.Time) {
+	p.simRef = t
+	p.wallRef = time.Now()
+	p.synced = true
+}
+
+func (p *replayPacer) delay(t time.Time) time.Duration {
+	if p.speed <= 0 {
+		return 0
+	}
+
+	elapsed := time.Duration(float64(t.Sub(p.simRef)) / p.speed)
+	return time.Until(p.wallRef.Add(elapsed))
+}
+
+func (p *replayPacer) notify() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+type replayController interface {
+	Pause()
+	Resume()
+	Step(n int)
+}
+
+func readReplayControls(ctx context.Context, log *slog.Logger, r io.Reader, c replayController) error {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		fields := strings.Fields(strings.ToLower(s.Text()))
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "p", "pause":
+			c.Pause()
+		case "r", "resume":
+			c.Resume()
+		case "s", "step":
+			n := 1
+			if len(fields) > 1 {
+				v, err := strconv.Atoi(fields[1])
+				if err != nil || v < 1 {
+					log.Warn("invalid replay step count", slog.String("count", fields[1]))
+					continue
+				}
+				n = v
+			}
+			c.Step(n)
+		default:
+			log.Warn("unknown replay command", slog.String("command", fields[0]))
+		}
+	}
+
+	return s.Err()
+}
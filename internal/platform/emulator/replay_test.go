@@ -0,0 +1,108 @@
+package emulator
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockReplayController struct {
+	commands []string
+	steps    int
+}
+
+func (m *mockReplayController) Pause() {
+	m.commands = append(m.commands, "pause")
+}
+
+func (m *mockReplayController) Resume() {
+	m.commands = append(m.commands, "resume")
+}
+
+func (m *mockReplayController) Step(n int) {
+	m.commands = append(m.commands, "step")
+	m.steps += n
+}
+
+func TestReplayPacer_speed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	p := newReplayPacer(600, false)
+	start := time.Now()
+	for i := range 3 {
+		require.NoError(t, p.Wait(ctx, time.Unix(int64(i*60), 0)))
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 190*time.Millisecond)
+}
+
+func TestReplayPacer_max(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	p := newReplayPacer(0, false)
+	for i := range 1000 {
+		require.NoError(t, p.Wait(ctx, time.Unix(int64(i*3600), 0)))
+	}
+}
+
+func TestReplayPacer_pauseAndStep(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	p := newReplayPacer(0, true)
+	released := make(chan struct{})
+	go func() {
+		defer close(released)
+		for i := range 3 {
+			if err := p.Wait(ctx, time.Unix(int64(i), 0)); err != nil {
+				return
+			}
+			released <- struct{}{}
+		}
+	}()
+
+	select {
+	case <-released:
+		t.Fatal("bar released while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	p.Step(1)
+	<-released
+
+	select {
+	case <-released:
+		t.Fatal("more bars released than stepped")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	p.Resume()
+	<-released
+	<-released
+}
+
+func TestReplayPacer_cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p := newReplayPacer(1, true)
+	assert.ErrorIs(t, p.Wait(ctx, time.Unix(0, 0)), context.Canceled)
+}
+
+func TestReadReplayControls(t *testing.T) {
+	c := &mockReplayController{}
+	r := strings.NewReader("pause\n\ns\nstep 5\nfoo\nstep x\nr\n")
+
+	err := readReplayControls(context.Background(), slog.New(slog.DiscardHandler), r, c)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"pause", "step", "step", "resume"}, c.commands)
+	assert.Equal(t, 6, c.steps)
+}
@@ -0,0 +1,286 @@
+package emulator
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/gamma-omg/trading-bot/internal/market"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	colTime = iota
+	colOpen
+	colHigh
+	colLow
+	colClose
+	colVolume
+	colCount
+)
+
+var columnNames = [colCount]string{"time", "open", "high", "low", "close", "volume"}
+
+var columnAliases = [colCount][]string{
+	{"timestamp", "unix", "time", "open_time", "opentime", "ts", "datetime", "date", "t"},
+	{"open", "o", "open_price"},
+	{"high", "h", "high_price"},
+	{"low", "l", "low_price"},
+	{"close", "c", "close_price", "price"},
+	{"volume", "vol", "v", "base_volume", "volume_base"},
+}
+
+var timeUnits = map[string]time.Duration{
+	"s":  time.Second,
+	"ms": time.Millisecond,
+	"us": time.Microsecond,
+	"ns": time.Nanosecond,
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+type csvLayout struct {
+	header  bool
+	columns [colCount]int
+	time    *timeParser
+}
+
+func sniffDelimiter(schema config.CsvSchema, r *bufio.Reader) (rune, error) {
+	if schema.Delimiter != "" {
+		d := schema.Delimiter
+		if d == `\t` || strings.EqualFold(d, "tab") {
+			return '\t', nil
+		}
+		if utf8.RuneCountInString(d) != 1 {
+			return 0, fmt.Errorf("invalid csv delimiter: %q", d)
+		}
+
+		c, _ := utf8.DecodeRuneInString(d)
+		return c, nil
+	}
+
+	line, _ := r.Peek(r.Size())
+	if i := strings.IndexByte(string(line), '\n'); i >= 0 {
+		line = line[:i]
+	}
+
+	best, bestCount := ',', 0
+	for _, c := range []rune{',', ';', '\t', '|'} {
+		if n := strings.Count(string(line), string(c)); n > bestCount {
+			best, bestCount = c, n
+		}
+	}
+
+	return best, nil
+}
+
+func newCsvLayout(schema config.CsvSchema, first []string) (*csvLayout, error) {
+	tp, err := newTimeParser(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &csvLayout{time: tp}
+	if schema.Header != nil {
+		l.header = *schema.Header
+	} else {
+		l.header = isHeader(first)
+	}
+
+	refs := [colCount]string{
+		schema.Columns.Time,
+		schema.Columns.Open,
+		schema.Columns.High,
+		schema.Columns.Low,
+		schema.Columns.Close,
+		schema.Columns.Volume,
+	}
+
+	for c, ref := range refs {
+		idx, err := resolveColumn(c, ref, first, l.header)
+		if err != nil {
+			return nil, err
+		}
+		l.columns[c] = idx
+	}
+
+	return l, nil
+}
+
+func resolveColumn(c int, ref string, header []string, hasHeader bool) (int, error) {
+	ref = strings.TrimSpace(ref)
+	if ref != "" {
+		if idx, err := strconv.Atoi(ref); err == nil {
+			if idx < 0 {
+				return 0, fmt.Errorf("invalid %s column index: %d", columnNames[c], idx)
+			}
+			return idx, nil
+		}
+
+		if !hasHeader {
+			return 0, fmt.Errorf("%s column is referenced by name %q but the file has no header", columnNames[c], ref)
+		}
+
+		if idx := findColumn(header, ref); idx >= 0 {
+			return idx, nil
+		}
+
+		return 0, fmt.Errorf("%s column %q not found in csv header", columnNames[c], ref)
+	}
+
+	if hasHeader {
+		for _, alias := range columnAliases[c] {
+			if idx := findColumn(header, alias); idx >= 0 {
+				return idx, nil
+			}
+		}
+	}
+
+	return c, nil
+}
+
+func findColumn(header []string, name string) int {
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(h), name) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func isHeader(record []string) bool {
+	for _, f := range record {
+		if _, err := strconv.ParseFloat(strings.TrimSpace(f), 64); err == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (l *csvLayout) parse(record []string) (bar market.Bar, err error) {
+	for c, idx := range l.columns {
+		if idx >= len(record) {
+			err = fmt.Errorf("row has %d fields, %s column index is %d", len(record), columnNames[c], idx)
+			return
+		}
+	}
+
+	field := func(c int) string {
+		return strings.TrimSpace(record[l.columns[c]])
+	}
+
+	bar.Time, err = l.time.parse(field(colTime))
+	if err != nil {
+		err = fmt.Errorf("failed to parse bar time: %w", err)
+		return
+	}
+
+	prices := [...]*decimal.Decimal{
+		colOpen:   &bar.Open,
+		colHigh:   &bar.High,
+		colLow:    &bar.Low,
+		colClose:  &bar.Close,
+		colVolume: &bar.Volume,
+	}
+	for c := colOpen; c < colCount; c++ {
+		*prices[c], err = decimal.NewFromString(field(c))
+		if err != nil {
+			err = fmt.Errorf("failed to read %s price: %w", columnNames[c], err)
+			return
+		}
+	}
+
+	return
+}
+
+type timeParser struct {
+	unit   time.Duration
+	layout string
+	loc    *time.Location
+	auto   bool
+}
+
+func newTimeParser(schema config.CsvSchema) (*timeParser, error) {
+	p := &timeParser{loc: time.UTC, layout: schema.TimeLayout}
+	if schema.Timezone != "" {
+		loc, err := time.LoadLocation(schema.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", schema.Timezone, err)
+		}
+		p.loc = loc
+	}
+
+	if schema.TimeUnit != "" {
+		unit, ok := timeUnits[strings.ToLower(schema.TimeUnit)]
+		if !ok {
+			return nil, fmt.Errorf("invalid time unit %q, expected one of s, ms, us, ns", schema.TimeUnit)
+		}
+		p.unit = unit
+	}
+
+	p.auto = p.unit == 0 && p.layout == ""
+	return p, nil
+}
+
+func (p *timeParser) parse(s string) (time.Time, error) {
+	if p.auto {
+		if err := p.detect(s); err != nil {
+			return time.Time{}, err
+		}
+		p.auto = false
+	}
+
+	if p.layout != "" {
+		return time.ParseInLocation(p.layout, s, p.loc)
+	}
+
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(0, d.Mul(decimal.NewFromInt(int64(p.unit))).IntPart()), nil
+}
+
+func (p *timeParser) detect(s string) error {
+	if d, err := decimal.NewFromString(s); err == nil {
+		p.unit = epochUnit(d.Abs().IntPart())
+		return nil
+	}
+
+	for _, layout := range timeLayouts {
+		if _, err := time.ParseInLocation(layout, s, p.loc); err == nil {
+			p.layout = layout
+			return nil
+		}
+	}
+
+	return errors.New("unknown timestamp format: " + s)
+}
+
+func epochUnit(v int64) time.Duration {
+	switch {
+	case v < 1e11:
+		return time.Second
+	case v < 1e14:
+		return time.Millisecond
+	case v < 1e17:
+		return time.Microsecond
+	default:
+		return time.Nanosecond
+	}
+}
@@ -0,0 +1,169 @@
+package emulator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/gamma-omg/trading-bot/internal/market"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readSchemaBars(t *testing.T, src string, schema config.CsvSchema) ([]market.Bar, error) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	br, closer, err := newBarReaderWithSchema(writeCsv(t, "data", src), schema, func(market.Bar) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	var bars []market.Bar
+	for r := range br.Read(ctx) {
+		if r.err != nil {
+			return bars, r.err
+		}
+		bars = append(bars, r.bar)
+	}
+
+	return bars, nil
+}
+
+func TestSchema(t *testing.T) {
+	header := true
+	noHeader := false
+
+	tbl := []struct {
+		src    string
+		schema config.CsvSchema
+		time   time.Time
+		open   float64
+		close  float64
+		volume float64
+	}{
+		{
+			src: `unix,date,symbol,open,high,low,close,Volume BTC,Volume USD
+1700000000123,2023-11-14 22:13:20,BTC/USD,100,110,90,105,2,210`,
+			schema: config.CsvSchema{Columns: config.CsvColumns{Volume: "Volume BTC"}},
+			time:   time.UnixMilli(1700000000123),
+			open:   100,
+			close:  105,
+			volume: 2,
+		},
+		{
+			src: `open_time;o;h;l;c;v
+1700000000000000;1.5;2;1;1.75;10`,
+			time:   time.UnixMicro(1700000000000000),
+			open:   1.5,
+			close:  1.75,
+			volume: 10,
+		},
+		{
+			src:    `2023-11-14T22:13:20Z	1	2	0.5	1.5	3`,
+			time:   time.Unix(1700000000, 0),
+			open:   1,
+			close:  1.5,
+			volume: 3,
+		},
+		{
+			src: `2023-11-14 23:13:20|1|2|0.5|1.5|3`,
+			schema: config.CsvSchema{
+				Delimiter: "|",
+				Header:    &noHeader,
+				Timezone:  "Europe/Berlin",
+			},
+			time:   time.Unix(1700000000, 0),
+			open:   1,
+			close:  1.5,
+			volume: 3,
+		},
+		{
+			src: `vol,close,low,high,open,ts
+7,4,3,5,2,1700000000.5`,
+			schema: config.CsvSchema{
+				Header:   &header,
+				TimeUnit: "s",
+			},
+			time:   time.Unix(1700000000, 500_000_000),
+			open:   2,
+			close:  4,
+			volume: 7,
+		},
+		{
+			src: `14/11/2023 22:13,9,1,2,0.5,1.5,3`,
+			schema: config.CsvSchema{
+				TimeLayout: "02/01/2006 15:04",
+				Columns: config.CsvColumns{
+					Time:   "0",
+					Open:   "2",
+					High:   "3",
+					Low:    "4",
+					Close:  "5",
+					Volume: "6",
+				},
+			},
+			time:   time.Unix(1699999980, 0),
+			open:   1,
+			close:  1.5,
+			volume: 3,
+		},
+		{
+			src:    `1700000000000000000,1,2,0.5,1.5,3`,
+			time:   time.Unix(1700000000, 0),
+			open:   1,
+			close:  1.5,
+			volume: 3,
+		},
+	}
+
+	for i, c := range tbl {
+		t.Run(fmt.Sprintf("case_%d", i), func(t *testing.T) {
+			bars, err := readSchemaBars(t, c.src, c.schema)
+			require.NoError(t, err)
+			require.Len(t, bars, 1)
+
+			assert.True(t, c.time.Equal(bars[0].Time), "expected %v got %v", c.time, bars[0].Time)
+			assert.True(t, decimal.NewFromFloat(c.open).Equal(bars[0].Open))
+			assert.True(t, decimal.NewFromFloat(c.close).Equal(bars[0].Close))
+			assert.True(t, decimal.NewFromFloat(c.volume).Equal(bars[0].Volume))
+		})
+	}
+}
+
+func TestSchema_errors(t *testing.T) {
+	noHeader := false
+
+	tbl := []struct {
+		src    string
+		schema config.CsvSchema
+	}{
+		{src: "1700000000,1,2,3", schema: config.CsvSchema{}},
+		{src: "1700000000,1,2,3,4,5", schema: config.CsvSchema{TimeUnit: "minutes"}},
+		{src: "1700000000,1,2,3,4,5", schema: config.CsvSchema{Timezone: "Mars/Olympus"}},
+		{src: "1700000000,1,2,3,4,5", schema: config.CsvSchema{Delimiter: ";;"}},
+		{src: "1700000000,1,2,3,4,5", schema: config.CsvSchema{Header: &noHeader, Columns: config.CsvColumns{Open: "open"}}},
+		{src: "time,open,high,low,close,volume\n1,2,3,4,5,6", schema: config.CsvSchema{Columns: config.CsvColumns{Close: "last"}}},
+		{src: "yesterday,1,2,3,4,5", schema: config.CsvSchema{Header: &noHeader}},
+	}
+
+	for i, c := range tbl {
+		t.Run(fmt.Sprintf("case_%d", i), func(t *testing.T) {
+			_, err := readSchemaBars(t, c.src, c.schema)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEpochUnit(t *testing.T) {
+	assert.Equal(t, time.Second, epochUnit(1700000000))
+	assert.Equal(t, time.Millisecond, epochUnit(1700000000000))
+	assert.Equal(t, time.Microsecond, epochUnit(1700000000000000))
+	assert.Equal(t, time.Nanosecond, epochUnit(1700000000000000000))
+}
@@ -0,0 +1,273 @@
+package emulator
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/gamma-omg/trading-bot/internal/market"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	overlapDedupe = "dedupe"
+	overlapReject = "reject"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+type barSource struct {
+	files   []string
+	schema  config.CsvSchema
+	overlap string
+	cache   *barCache
+	filter  barFilter
+}
+
+func newBarSource(path string, schema config.CsvSchema, overlap string, cache *barCache, filter barFilter) (*barSource, error) {
+	files, err := expandDataPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &barSource{
+		files:   files,
+		schema:  schema,
+		overlap: overlap,
+		cache:   cache,
+		filter:  filter,
+	}, nil
+}
+
+func (s *barSource) Read(ctx context.Context) <-chan barReadResult {
+	out := make(chan barReadResult)
+	go func() {
+		defer close(out)
+
+		files, err := s.sortFiles(ctx)
+		if err != nil {
+			out <- barReadResult{market.Bar{}, err}
+			return
+		}
+
+		var last time.Time
+		for i, f := range files {
+			var ok bool
+			if last, ok = s.readFile(ctx, f, last, i > 0, out); !ok {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+func (s *barSource) readFile(ctx context.Context, path string, last time.Time, boundary bool, out chan<- barReadResult) (time.Time, bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for r := range s.readBars(ctx, path) {
+		if r.err != nil {
+			out <- barReadResult{market.Bar{}, fmt.Errorf("failed to read %s: %w", path, r.err)}
+			return last, false
+		}
+
+		if boundary {
+			if !r.bar.Time.After(last) {
+				if s.overlap == overlapReject {
+					out <- barReadResult{market.Bar{}, fmt.Errorf("%s overlaps previous data at %s", path, r.bar.Time)}
+					return last, false
+				}
+				continue
+			}
+			boundary = false
+		}
+
+		last = r.bar.Time
+		if !s.filter(r.bar) {
+			continue
+		}
+
+		select {
+		case out <- barReadResult{r.bar, nil}:
+		case <-ctx.Done():
+			return last, false
+		}
+	}
+
+	return last, ctx.Err() == nil
+}
+
+func (s *barSource) sortFiles(ctx context.Context) ([]string, error) {
+	if len(s.files) < 2 {
+		return s.files, nil
+	}
+
+	starts := make(map[string]time.Time, len(s.files))
+	for _, f := range s.files {
+		t, err := s.readFirstBarTime(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		starts[f] = t
+	}
+
+	files := append([]string(nil), s.files...)
+	sort.SliceStable(files, func(i, j int) bool {
+		return starts[files[i]].Before(starts[files[j]])
+	})
+
+	return files, nil
+}
+
+func (s *barSource) readFirstBarTime(ctx context.Context, path string) (time.Time, error) {
+	if s.cache != nil {
+		if t, ok := s.cache.firstBarTime(path, s.schema); ok {
+			return t, nil
+		}
+	}
+
+	// probing a file without a cache reads the csv directly, a cache fill would be discarded
+	ctx, cancel := context.WithCancel(ctx)
+	bars := readCsvBars(ctx, path, s.schema)
+	defer func() {
+		cancel()
+		for range bars {
+		}
+	}()
+
+	r, ok := <-bars
+	if !ok {
+		return time.Time{}, nil
+	}
+	if r.err != nil {
+		return time.Time{}, fmt.Errorf("failed to read %s: %w", path, r.err)
+	}
+
+	return r.bar.Time, nil
+}
+
+func (s *barSource) readBars(ctx context.Context, path string) <-chan barReadResult {
+	if s.cache != nil {
+		return s.cache.Read(ctx, path, s.schema)
+	}
+
+	return readCsvBars(ctx, path, s.schema)
+}
+
+func readCsvBars(ctx context.Context, path string, schema config.CsvSchema) <-chan barReadResult {
+	out := make(chan barReadResult)
+	go func() {
+		defer close(out)
+
+		rdr, closer, err := newBarReaderWithSchema(path, schema, func(market.Bar) bool { return true })
+		if err != nil {
+			sendBarResult(ctx, out, barReadResult{market.Bar{}, err})
+			return
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		bars := rdr.Read(ctx)
+		defer func() {
+			// the reader must stop before the file and decoder are closed
+			cancel()
+			for range bars {
+			}
+			closer.Close()
+		}()
+
+		for r := range bars {
+			if !sendBarResult(ctx, out, r) || r.err != nil {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+func expandDataPath(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err == nil && !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	if err == nil {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list data directory: %w", err)
+		}
+
+		for _, e := range entries {
+			if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			files = append(files, filepath.Join(path, e.Name()))
+		}
+	} else {
+		files, err = filepath.Glob(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid data path pattern %s: %w", path, err)
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no data files found at %s", path)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+type multiCloser []func() error
+
+func (c multiCloser) Close() error {
+	var err error
+	for _, f := range c {
+		err = errors.Join(err, f())
+	}
+
+	return err
+}
+
+func openDataFile(path string) (io.Reader, io.Closer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	br := bufio.NewReader(f)
+	magic, _ := br.Peek(len(zstdMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("failed to open gzip stream: %w", err)
+		}
+		return gz, multiCloser{gz.Close, f.Close}, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("failed to open zstd stream: %w", err)
+		}
+		return zr, multiCloser{func() error { zr.Close(); return nil }, f.Close}, nil
+	default:
+		return br, f, nil
+	}
+}
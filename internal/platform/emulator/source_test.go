@@ -0,0 +1,151 @@
+package emulator
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/gamma-omg/trading-bot/internal/market"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	return path
+}
+
+func gzipData(t *testing.T, src string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(src))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func zstdData(t *testing.T, src string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := zstd.NewWriter(&buf)
+	require.NoError(t, err)
+	_, err = w.Write([]byte(src))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func readSource(t *testing.T, path, overlap string) ([]time.Time, error) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	src, err := newBarSource(path, config.CsvSchema{}, overlap, nil, func(market.Bar) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+
+	var times []time.Time
+	for r := range src.Read(ctx) {
+		if r.err != nil {
+			return times, r.err
+		}
+		times = append(times, r.bar.Time)
+	}
+
+	return times, nil
+}
+
+func unixTimes(ts ...int64) []time.Time {
+	times := make([]time.Time, len(ts))
+	for i, v := range ts {
+		times[i] = time.Unix(v, 0)
+	}
+
+	return times
+}
+
+const (
+	janBars = `timestamp,open,high,low,close,volume
+60,1,1,1,1,1
+120,1,1,1,1,1
+180,1,1,1,1,1`
+	febBars = `timestamp,open,high,low,close,volume
+180,2,2,2,2,2
+240,2,2,2,2,2`
+	marBars = `timestamp,open,high,low,close,volume
+300,3,3,3,3,3`
+)
+
+func TestSource_compressed(t *testing.T) {
+	dir := t.TempDir()
+	tbl := map[string][]byte{
+		"plain.csv":    []byte(janBars),
+		"bars.csv.gz":  gzipData(t, janBars),
+		"bars.csv.zst": zstdData(t, janBars),
+		"noext":        zstdData(t, janBars),
+	}
+
+	for name, data := range tbl {
+		t.Run(name, func(t *testing.T) {
+			times, err := readSource(t, writeFile(t, dir, name, data), "")
+			require.NoError(t, err)
+			assert.Equal(t, unixTimes(60, 120, 180), times)
+		})
+	}
+}
+
+func TestSource_directory(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a_march.csv.gz", gzipData(t, marBars))
+	writeFile(t, dir, "b_february.csv.zst", zstdData(t, febBars))
+	writeFile(t, dir, "c_january.csv", []byte(janBars))
+	writeFile(t, dir, ".hidden", []byte("garbage"))
+
+	times, err := readSource(t, dir, overlapDedupe)
+	require.NoError(t, err)
+	assert.Equal(t, unixTimes(60, 120, 180, 240, 300), times)
+}
+
+func TestSource_glob(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "btc-2025-02.csv", []byte(febBars))
+	writeFile(t, dir, "btc-2025-01.csv", []byte(janBars))
+	writeFile(t, dir, "eth-2025-01.csv", []byte(marBars))
+
+	times, err := readSource(t, filepath.Join(dir, "btc-*.csv"), "")
+	require.NoError(t, err)
+	assert.Equal(t, unixTimes(60, 120, 180, 240), times)
+}
+
+func TestSource_rejectOverlap(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "01.csv", []byte(janBars))
+	writeFile(t, dir, "02.csv", []byte(febBars))
+
+	_, err := readSource(t, dir, overlapReject)
+	assert.ErrorContains(t, err, "overlaps")
+}
+
+func TestSource_noFiles(t *testing.T) {
+	_, err := readSource(t, filepath.Join(t.TempDir(), "*.csv"), "")
+	assert.Error(t, err)
+}
+
+func TestNewTradingEmulator_unknownOverlap(t *testing.T) {
+	_, err := NewTradingEmulator(nil, config.Emulator{Overlap: "merge"})
+	assert.Error(t, err)
+}
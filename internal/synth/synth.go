@@ -0,0 +1,286 @@
+package synth
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/gamma-omg/trading-bot/internal/fsutil"
+	"github.com/gamma-omg/trading-bot/internal/market"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	year            = 365 * 24 * time.Hour
+	defaultDecimals = 4
+	defaultVolume   = 1
+)
+
+type Generator struct {
+	cfg      config.SyntheticSeries
+	rng      *rand.Rand
+	dt       float64
+	interval time.Duration
+	t        time.Time
+	price    float64
+	variance float64
+	shock    float64
+	regime   int
+}
+
+func NewGenerator(cfg config.SyntheticSeries, seed uint64, start time.Time, interval time.Duration) (*Generator, error) {
+	if err := validateSeries(cfg, interval); err != nil {
+		return nil, err
+	}
+
+	if cfg.Decimals <= 0 {
+		cfg.Decimals = defaultDecimals
+	}
+	if cfg.Volume <= 0 {
+		cfg.Volume = defaultVolume
+	}
+
+	g := &Generator{
+		cfg:      cfg,
+		rng:      rand.New(rand.NewPCG(seed, seed)),
+		dt:       float64(interval) / float64(year),
+		interval: interval,
+		t:        start,
+		price:    cfg.Price,
+	}
+	if len(cfg.Regimes) > 0 {
+		g.regime = g.rng.IntN(len(cfg.Regimes))
+	}
+	g.variance = g.baseVariance()
+
+	return g, nil
+}
+
+func validateSeries(cfg config.SyntheticSeries, interval time.Duration) error {
+	if interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+	if cfg.Price <= 0 {
+		return errors.New("initial price must be positive")
+	}
+	if cfg.Volatility < 0 {
+		return errors.New("volatility cannot be negative")
+	}
+	if g := cfg.Garch; g != nil && (g.Alpha < 0 || g.Beta < 0 || g.Alpha+g.Beta >= 1) {
+		return errors.New("garch alpha and beta must be non-negative with alpha + beta < 1")
+	}
+	if j := cfg.Jumps; j != nil && (j.Intensity < 0 || j.StdDev < 0) {
+		return errors.New("jump intensity and std_dev cannot be negative")
+	}
+	for _, r := range cfg.Regimes {
+		if r.Duration <= 0 || r.Volatility < 0 {
+			return fmt.Errorf("regime %s must have a positive duration and non-negative volatility", r.Name)
+		}
+	}
+	for _, t := range cfg.Trends {
+		if !t.Start.Before(t.End) {
+			return errors.New("trend start must be before end")
+		}
+	}
+
+	return nil
+}
+
+func (g *Generator) Next() market.Bar {
+	g.switchRegime()
+
+	drift, vol := g.params()
+	for _, t := range g.cfg.Trends {
+		if !g.t.Before(t.Start) && g.t.Before(t.End) {
+			drift += t.Drift
+		}
+	}
+
+	base := vol * vol * g.dt
+	if garch := g.cfg.Garch; garch != nil {
+		g.variance = (1-garch.Alpha-garch.Beta)*base + garch.Alpha*g.shock*g.shock + garch.Beta*g.variance
+	} else {
+		g.variance = base
+	}
+
+	g.shock = math.Sqrt(g.variance) * g.rng.NormFloat64()
+	r := drift*g.dt - g.variance/2 + g.shock + g.jump()
+
+	open := g.price
+	closePrice := open * math.Exp(r)
+	spread := math.Sqrt(g.variance) / 2
+	high := math.Max(open, closePrice) * math.Exp(math.Abs(g.rng.NormFloat64())*spread)
+	low := math.Min(open, closePrice) * math.Exp(-math.Abs(g.rng.NormFloat64())*spread)
+
+	activity := 1.0
+	if g.variance > 0 {
+		activity += math.Abs(r) / math.Sqrt(g.variance)
+	}
+	volume := g.cfg.Volume * activity * math.Exp(g.rng.NormFloat64()/4)
+
+	bar := market.Bar{
+		Time:   g.t,
+		Open:   g.round(open),
+		High:   g.round(high),
+		Low:    g.round(low),
+		Close:  g.round(closePrice),
+		Volume: decimal.NewFromFloat(volume).Round(int32(g.cfg.Decimals)),
+	}
+
+	g.price = closePrice
+	g.t = g.t.Add(g.interval)
+	return bar
+}
+
+func (g *Generator) Regime() string {
+	if len(g.cfg.Regimes) == 0 {
+		return ""
+	}
+
+	return g.cfg.Regimes[g.regime].Name
+}
+
+func (g *Generator) params() (float64, float64) {
+	if len(g.cfg.Regimes) == 0 {
+		return g.cfg.Drift, g.cfg.Volatility
+	}
+
+	r := g.cfg.Regimes[g.regime]
+	return r.Drift, r.Volatility
+}
+
+func (g *Generator) baseVariance() float64 {
+	_, vol := g.params()
+	return vol * vol * g.dt
+}
+
+func (g *Generator) switchRegime() {
+	n := len(g.cfg.Regimes)
+	if n < 2 {
+		return
+	}
+
+	p := float64(g.interval) / float64(g.cfg.Regimes[g.regime].Duration)
+	if g.rng.Float64() >= p {
+		return
+	}
+
+	next := g.rng.IntN(n - 1)
+	if next >= g.regime {
+		next++
+	}
+	g.regime = next
+}
+
+func (g *Generator) jump() float64 {
+	j := g.cfg.Jumps
+	if j == nil || j.Intensity == 0 {
+		return 0
+	}
+
+	var sum float64
+	for range poisson(g.rng, j.Intensity*g.dt) {
+		sum += j.Mean + j.StdDev*g.rng.NormFloat64()
+	}
+
+	return sum
+}
+
+func (g *Generator) round(v float64) decimal.Decimal {
+	return decimal.NewFromFloat(v).Round(int32(g.cfg.Decimals))
+}
+
+func poisson(rng *rand.Rand, lambda float64) int {
+	limit := math.Exp(-lambda)
+	n := 0
+	for p := rng.Float64(); p > limit; p *= rng.Float64() {
+		n++
+	}
+
+	return n
+}
+
+func Bars(cfg config.Synthetic, symbol string) ([]market.Bar, error) {
+	s, ok := cfg.Series[symbol]
+	if !ok {
+		return nil, fmt.Errorf("no synthetic series for %s", symbol)
+	}
+	if cfg.Bars <= 0 {
+		return nil, errors.New("number of bars must be positive")
+	}
+
+	g, err := NewGenerator(s, seriesSeed(cfg.Seed, symbol), cfg.Start, cfg.Interval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid synthetic series %s: %w", symbol, err)
+	}
+
+	bars := make([]market.Bar, cfg.Bars)
+	for i := range bars {
+		bars[i] = g.Next()
+	}
+
+	return bars, nil
+}
+
+func seriesSeed(seed uint64, symbol string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(symbol))
+	return seed ^ h.Sum64()
+}
+
+func Generate(cfg config.Synthetic) error {
+	for symbol, s := range cfg.Series {
+		if s.Path == "" {
+			return fmt.Errorf("synthetic series %s has no path", symbol)
+		}
+
+		bars, err := Bars(cfg, symbol)
+		if err != nil {
+			return err
+		}
+
+		err = fsutil.WriteFile(s.Path, func(w io.Writer) error {
+			return WriteCsv(w, bars)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write synthetic series %s: %w", symbol, err)
+		}
+	}
+
+	return nil
+}
+
+func WriteCsv(w io.Writer, bars []market.Bar) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"timestamp", "open", "high", "low", "close", "volume"}); err != nil {
+		return fmt.Errorf("failed to write csv header: %w", err)
+	}
+
+	for _, b := range bars {
+		err := cw.Write([]string{
+			strconv.FormatInt(b.Time.Unix(), 10),
+			b.Open.String(),
+			b.High.String(),
+			b.Low.String(),
+			b.Close.String(),
+			b.Volume.String(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write bar: %w", err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write bars: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,270 @@
+package synth
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gamma-omg/trading-bot/internal/config"
+	"github.com/gamma-omg/trading-bot/internal/market"
+	"github.com/gamma-omg/trading-bot/internal/platform/emulator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func generate(t *testing.T, s config.SyntheticSeries, seed uint64, n int) []market.Bar {
+	t.Helper()
+
+	bars, err := Bars(config.Synthetic{
+		Seed:     seed,
+		Start:    start,
+		Interval: time.Hour,
+		Bars:     n,
+		Series:   map[string]config.SyntheticSeries{"BTC": s},
+	}, "BTC")
+	require.NoError(t, err)
+	return bars
+}
+
+func logReturns(bars []market.Bar) []float64 {
+	r := make([]float64, len(bars))
+	for i, b := range bars {
+		o, _ := b.Open.Float64()
+		c, _ := b.Close.Float64()
+		r[i] = math.Log(c / o)
+	}
+
+	return r
+}
+
+func moments(values []float64) (mean, variance, kurtosis float64) {
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	var m4 float64
+	for _, v := range values {
+		d := (v - mean) * (v - mean)
+		variance += d
+		m4 += d * d
+	}
+	variance /= float64(len(values))
+	m4 /= float64(len(values))
+
+	return mean, variance, m4 / (variance * variance)
+}
+
+func squaredAutocorrelation(r []float64) float64 {
+	sq := make([]float64, len(r))
+	for i, v := range r {
+		sq[i] = v * v
+	}
+	mean, variance, _ := moments(sq)
+
+	var cov float64
+	for i := 1; i < len(sq); i++ {
+		cov += (sq[i] - mean) * (sq[i-1] - mean)
+	}
+
+	return cov / float64(len(sq)-1) / variance
+}
+
+func TestBars_deterministic(t *testing.T) {
+	s := config.SyntheticSeries{Price: 100, Volatility: 0.5, Jumps: &config.Jumps{Intensity: 50, StdDev: 0.02}}
+
+	first := generate(t, s, 7, 500)
+	second := generate(t, s, 7, 500)
+	other := generate(t, s, 8, 500)
+
+	assert.Equal(t, first, second)
+	assert.NotEqual(t, first, other)
+
+	cfg := config.Synthetic{
+		Seed:     7,
+		Start:    start,
+		Interval: time.Hour,
+		Bars:     500,
+		Series:   map[string]config.SyntheticSeries{"BTC": s, "ETH": s},
+	}
+	btc, err := Bars(cfg, "BTC")
+	require.NoError(t, err)
+	eth, err := Bars(cfg, "ETH")
+	require.NoError(t, err)
+
+	assert.Equal(t, first, btc, "adding a series must not change the others")
+	assert.NotEqual(t, btc, eth)
+}
+
+func TestBars_ohlc(t *testing.T) {
+	bars := generate(t, config.SyntheticSeries{Price: 100, Volatility: 0.8, Volume: 10}, 1, 2000)
+
+	for i, b := range bars {
+		assert.Equal(t, start.Add(time.Duration(i)*time.Hour), b.Time)
+		assert.True(t, b.High.GreaterThanOrEqual(b.Open) && b.High.GreaterThanOrEqual(b.Close), "bar %d high", i)
+		assert.True(t, b.Low.LessThanOrEqual(b.Open) && b.Low.LessThanOrEqual(b.Close), "bar %d low", i)
+		assert.True(t, b.Volume.IsPositive(), "bar %d volume", i)
+		if i > 0 {
+			assert.True(t, b.Open.Equal(bars[i-1].Close), "bar %d open", i)
+		}
+	}
+}
+
+func TestBars_gbmVolatility(t *testing.T) {
+	bars := generate(t, config.SyntheticSeries{Price: 100, Volatility: 0.6, Decimals: 8}, 3, 50000)
+
+	_, variance, kurtosis := moments(logReturns(bars))
+	annualized := math.Sqrt(variance * float64(year/time.Hour))
+
+	assert.InDelta(t, 0.6, annualized, 0.02)
+	assert.InDelta(t, 3, kurtosis, 0.2)
+	assert.InDelta(t, 0, squaredAutocorrelation(logReturns(bars)), 0.03)
+}
+
+func TestBars_garchClustering(t *testing.T) {
+	s := config.SyntheticSeries{Price: 100, Volatility: 0.6, Decimals: 8, Garch: &config.Garch{Alpha: 0.15, Beta: 0.8}}
+	r := logReturns(generate(t, s, 3, 50000))
+
+	_, variance, _ := moments(r)
+	assert.InDelta(t, 0.6, math.Sqrt(variance*float64(year/time.Hour)), 0.1)
+	assert.Greater(t, squaredAutocorrelation(r), 0.1)
+}
+
+func TestBars_jumps(t *testing.T) {
+	s := config.SyntheticSeries{Price: 100, Volatility: 0.3, Decimals: 8, Jumps: &config.Jumps{Intensity: 100, Mean: -0.01, StdDev: 0.03}}
+	_, _, kurtosis := moments(logReturns(generate(t, s, 5, 50000)))
+
+	assert.Greater(t, kurtosis, 6.0)
+}
+
+func TestBars_trend(t *testing.T) {
+	trend := config.SyntheticTrend{Start: start.Add(1000 * time.Hour), End: start.Add(2000 * time.Hour), Drift: 20}
+	bars := generate(t, config.SyntheticSeries{Price: 100, Volatility: 0.2, Decimals: 8, Trends: []config.SyntheticTrend{trend}}, 9, 3000)
+
+	price := func(i int) float64 {
+		f, _ := bars[i].Open.Float64()
+		return f
+	}
+
+	expected := 20 * float64(1000*time.Hour) / float64(year)
+	assert.InDelta(t, expected, math.Log(price(2000)/price(1000)), 0.15)
+	assert.InDelta(t, 0, math.Log(price(1000)/price(0)), 0.2)
+	assert.InDelta(t, 0, math.Log(price(2999)/price(2000)), 0.2)
+}
+
+func TestGenerator_regimes(t *testing.T) {
+	s := config.SyntheticSeries{
+		Price:    100,
+		Decimals: 8,
+		Regimes: []config.Regime{
+			{Name: "calm", Volatility: 0.1, Duration: 200 * time.Hour},
+			{Name: "storm", Volatility: 1.5, Duration: 100 * time.Hour},
+		},
+	}
+
+	g, err := NewGenerator(s, 11, start, time.Hour)
+	require.NoError(t, err)
+
+	returns := map[string][]float64{}
+	switches := 0
+	prev := g.Regime()
+	for range 30000 {
+		b := g.Next()
+		o, _ := b.Open.Float64()
+		c, _ := b.Close.Float64()
+		returns[g.Regime()] = append(returns[g.Regime()], math.Log(c/o))
+		if g.Regime() != prev {
+			switches++
+			prev = g.Regime()
+		}
+	}
+
+	require.Len(t, returns, 2)
+	assert.InDelta(t, 30000/150, switches, 60)
+
+	_, calm, _ := moments(returns["calm"])
+	_, storm, _ := moments(returns["storm"])
+	assert.InDelta(t, 0.1, math.Sqrt(calm*float64(year/time.Hour)), 0.02)
+	assert.InDelta(t, 1.5, math.Sqrt(storm*float64(year/time.Hour)), 0.1)
+}
+
+func TestNewGenerator_invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.SyntheticSeries
+	}{
+		{name: "no price", cfg: config.SyntheticSeries{Volatility: 0.1}},
+		{name: "negative volatility", cfg: config.SyntheticSeries{Price: 1, Volatility: -1}},
+		{name: "unstable garch", cfg: config.SyntheticSeries{Price: 1, Garch: &config.Garch{Alpha: 0.5, Beta: 0.5}}},
+		{name: "negative jumps", cfg: config.SyntheticSeries{Price: 1, Jumps: &config.Jumps{Intensity: -1}}},
+		{name: "regime without duration", cfg: config.SyntheticSeries{Price: 1, Regimes: []config.Regime{{Name: "a"}}}},
+		{name: "inverted trend", cfg: config.SyntheticSeries{Price: 1, Trends: []config.SyntheticTrend{{Start: start, End: start}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewGenerator(tt.cfg, 1, start, time.Hour)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := NewGenerator(config.SyntheticSeries{Price: 1}, 1, start, 0)
+	assert.Error(t, err)
+}
+
+func TestGenerate_readableByEmulator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "btc.csv")
+	cfg := config.Synthetic{
+		Seed:     1,
+		Start:    start,
+		Interval: time.Minute,
+		Bars:     300,
+		Series: map[string]config.SyntheticSeries{
+			"BTC": {Path: path, Price: 50000, Volatility: 0.7, Volume: 5, Decimals: 2},
+		},
+	}
+	require.NoError(t, Generate(cfg))
+
+	expected, err := Bars(cfg, "BTC")
+	require.NoError(t, err)
+
+	emu, err := emulator.NewTradingEmulator(slog.New(slog.DiscardHandler), config.Emulator{
+		Data:  map[string]string{"BTC": path},
+		Start: start.Add(-time.Second),
+		End:   start.Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	barsCh, errCh := emu.GetBars(ctx, "BTC")
+	var bars []market.Bar
+	for b := range barsCh {
+		bars = append(bars, b)
+	}
+	for err := range errCh {
+		require.NoError(t, err)
+	}
+
+	require.Len(t, bars, len(expected))
+	for i := range bars {
+		assert.True(t, expected[i].Time.Equal(bars[i].Time))
+		assert.True(t, expected[i].Close.Equal(bars[i].Close), "bar %d close", i)
+		assert.True(t, expected[i].Volume.Equal(bars[i].Volume), "bar %d volume", i)
+	}
+}
+
+func TestGenerate_requiresPath(t *testing.T) {
+	err := Generate(config.Synthetic{
+		Interval: time.Minute,
+		Bars:     10,
+		Series:   map[string]config.SyntheticSeries{"BTC": {Price: 1}},
+	})
+	assert.Error(t, err)
+}